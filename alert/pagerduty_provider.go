@@ -0,0 +1,476 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	corealert "github.com/opsorch/opsorch-core/alert"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+// ProviderName is the registry key under which this adapter registers.
+const ProviderName = "pagerduty"
+
+// maxPageSize is the largest page size accepted by the PagerDuty list endpoints.
+const maxPageSize = 100
+
+// defaultQueryMaxIncidents caps the incidents whose alerts one Query reads.
+const defaultQueryMaxIncidents = 100
+
+// Config captures decrypted configuration from OpsOrch Core.
+type Config struct {
+	Source    string
+	APIToken  string
+	APIURL    string
+	FromEmail string // Email address of a valid PagerDuty user, required to resolve alerts
+	Retry     common.RetryPolicy
+	// RateLimitPerMinute caps outgoing requests per API token; 0 disables it.
+	RateLimitPerMinute int
+	Lookup             common.LookupOptions // name matching for Scope lookups
+	// QueryMaxIncidents caps the incidents whose alerts one Query reads, and
+	// so the alert requests it makes; 0 uses the default.
+	QueryMaxIncidents int
+}
+
+// PagerDutyProvider exposes PagerDuty alerts. PagerDuty only addresses alerts
+// through their parent incident, so alert IDs returned by this provider have
+// the form "<incident id>/<alert id>".
+type PagerDutyProvider struct {
+	cfg     Config
+	client  *http.Client
+	limiter *common.RateLimiter
+}
+
+// New constructs the provider from decrypted config.
+func New(cfg map[string]any) (corealert.Provider, error) {
+	parsed := parseConfig(cfg)
+	if parsed.APIToken == "" {
+		return nil, errors.New("pagerduty apiToken is required")
+	}
+	if parsed.APIURL == "" {
+		return nil, errors.New("pagerduty apiURL is required")
+	}
+	if err := parsed.Lookup.Validate(); err != nil {
+		return nil, err
+	}
+	var limiter *common.RateLimiter
+	if parsed.RateLimitPerMinute > 0 {
+		limiter = common.SharedRateLimiter(parsed.APIToken, parsed.RateLimitPerMinute)
+	}
+	return &PagerDutyProvider{
+		cfg:     parsed,
+		client:  common.NewHTTPClient(limiter),
+		limiter: limiter,
+	}, nil
+}
+
+func init() {
+	_ = corealert.RegisterProvider(ProviderName, New)
+}
+
+// Query searches alerts across incidents. Incidents are selected with the
+// scope and metadata filters one page at a time, and the alerts of each
+// incident are fetched and filtered by status and severity, so no more
+// incidents are read than needed to reach the limit. At most
+// QueryMaxIncidents incidents are searched.
+func (p *PagerDutyProvider) Query(ctx context.Context, q schema.AlertQuery) ([]schema.Alert, error) {
+	alertParams := url.Values{}
+	for _, status := range q.Statuses {
+		alertParams.Add("statuses[]", mapStatusToPD(status))
+	}
+
+	severities := map[string]bool{}
+	for _, sev := range q.Severities {
+		severities[mapSeverityToPD(sev)] = true
+	}
+
+	alerts := []schema.Alert{}
+	full := func() bool { return q.Limit > 0 && len(alerts) >= q.Limit }
+	maxIncidents := p.cfg.queryMaxIncidents()
+	scanned := 0
+	err := p.eachCandidateIncident(ctx, q, func(incidentID string) (bool, error) {
+		scanned++
+		err := p.eachAlert(ctx, incidentID, alertParams, func(a schema.Alert) bool {
+			if len(severities) == 0 || severities[mapSeverityToPD(a.Severity)] {
+				alerts = append(alerts, a)
+			}
+			return !full()
+		})
+		return !full() && scanned < maxIncidents, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// Get returns a single alert. The ID must be in "<incident id>/<alert id>" form.
+func (p *PagerDutyProvider) Get(ctx context.Context, id string) (schema.Alert, error) {
+	incidentID, alertID, err := splitAlertID(id)
+	if err != nil {
+		return schema.Alert{}, err
+	}
+
+	var result struct {
+		Alert pdAlert `json:"alert"`
+	}
+	if err := p.api().Get(ctx, "/incidents/"+incidentID+"/alerts/"+alertID, nil, &result); err != nil {
+		return schema.Alert{}, wrapNotFound(id, err)
+	}
+
+	return convertPDAlert(result.Alert, incidentID, p.cfg.Source), nil
+}
+
+// ListIncidentAlerts returns every alert grouped under an incident.
+func (p *PagerDutyProvider) ListIncidentAlerts(ctx context.Context, incidentID string) ([]schema.Alert, error) {
+	alerts, err := p.listAlerts(ctx, incidentID, url.Values{})
+	if err != nil {
+		return nil, wrapNotFound(incidentID, err)
+	}
+	return alerts, nil
+}
+
+// Resolve resolves a single alert. The ID must be in "<incident id>/<alert id>" form.
+func (p *PagerDutyProvider) Resolve(ctx context.Context, id string) (schema.Alert, error) {
+	incidentID, alertID, err := splitAlertID(id)
+	if err != nil {
+		return schema.Alert{}, err
+	}
+
+	payload := map[string]any{
+		"alert": map[string]any{
+			"type":   "alert",
+			"status": "resolved",
+		},
+	}
+
+	var result struct {
+		Alert pdAlert `json:"alert"`
+	}
+	if err := p.api().Put(ctx, "/incidents/"+incidentID+"/alerts/"+alertID, payload, &result); err != nil {
+		return schema.Alert{}, wrapNotFound(id, err)
+	}
+
+	return convertPDAlert(result.Alert, incidentID, p.cfg.Source), nil
+}
+
+// RateLimitBudget reports the client-side request budget when a rate limit is
+// configured.
+func (p *PagerDutyProvider) RateLimitBudget() (common.RateLimitBudget, bool) {
+	if p.limiter == nil {
+		return common.RateLimitBudget{}, false
+	}
+	return p.limiter.Budget(), true
+}
+
+// eachCandidateIncident calls fn with the ID of every incident whose alerts
+// should be searched, reading incident pages lazily. fn returns false to stop.
+func (p *PagerDutyProvider) eachCandidateIncident(ctx context.Context, q schema.AlertQuery, fn func(incidentID string) (bool, error)) error {
+	if v, ok := q.Metadata["incident_id"].(string); ok && v != "" {
+		_, err := fn(v)
+		return err
+	}
+
+	params := url.Values{}
+
+	// Alerts can only be open while their incident is open, so narrow the
+	// incident search when the caller asks for open alerts only.
+	if onlyOpen(q.Statuses) {
+		params.Add("statuses[]", "triggered")
+		params.Add("statuses[]", "acknowledged")
+	}
+
+	if q.Scope.Service != "" {
+		services, err := common.LookupServices(ctx, p.api(), q.Scope.Service, p.cfg.Lookup)
+		if err != nil {
+			return fmt.Errorf("lookup service by name %q: %w", q.Scope.Service, err)
+		}
+		// An unknown name must not widen the search to the whole account.
+		if len(services) == 0 {
+			return nil
+		}
+		for _, id := range services.IDs() {
+			params.Add("service_ids[]", id)
+		}
+	}
+
+	if q.Scope.Team != "" {
+		teams, err := common.LookupTeams(ctx, p.api(), q.Scope.Team, p.cfg.Lookup)
+		if err != nil {
+			return fmt.Errorf("lookup team by name %q: %w", q.Scope.Team, err)
+		}
+		if len(teams) == 0 {
+			return nil
+		}
+		for _, id := range teams.IDs() {
+			params.Add("team_ids[]", id)
+		}
+	}
+
+	if v, ok := q.Metadata["service_id"].(string); ok && v != "" {
+		params.Add("service_ids[]", v)
+	}
+	if v, ok := q.Metadata["team_id"].(string); ok && v != "" {
+		params.Add("team_ids[]", v)
+	}
+
+	offset := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		params.Set("limit", strconv.Itoa(maxPageSize))
+		params.Set("offset", strconv.Itoa(offset))
+
+		var result struct {
+			Incidents []struct {
+				ID string `json:"id"`
+			} `json:"incidents"`
+			More bool `json:"more"`
+		}
+		if err := p.api().Get(ctx, "/incidents", params, &result); err != nil {
+			return err
+		}
+		for _, inc := range result.Incidents {
+			more, err := fn(inc.ID)
+			if err != nil || !more {
+				return err
+			}
+		}
+		offset += len(result.Incidents)
+		if !result.More || len(result.Incidents) == 0 {
+			return nil
+		}
+	}
+}
+
+// listAlerts fetches all pages of alerts for an incident.
+func (p *PagerDutyProvider) listAlerts(ctx context.Context, incidentID string, params url.Values) ([]schema.Alert, error) {
+	var alerts []schema.Alert
+	err := p.eachAlert(ctx, incidentID, params, func(a schema.Alert) bool {
+		alerts = append(alerts, a)
+		return true
+	})
+	return alerts, err
+}
+
+// eachAlert calls fn with the alerts of an incident, page by page, until fn
+// returns false or the alerts run out.
+func (p *PagerDutyProvider) eachAlert(ctx context.Context, incidentID string, params url.Values, fn func(schema.Alert) bool) error {
+	offset := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		params.Set("limit", strconv.Itoa(maxPageSize))
+		params.Set("offset", strconv.Itoa(offset))
+
+		var result struct {
+			Alerts []pdAlert `json:"alerts"`
+			More   bool      `json:"more"`
+		}
+		if err := p.api().Get(ctx, "/incidents/"+incidentID+"/alerts", params, &result); err != nil {
+			return err
+		}
+		for _, a := range result.Alerts {
+			if !fn(convertPDAlert(a, incidentID, p.cfg.Source)) {
+				return nil
+			}
+		}
+		offset += len(result.Alerts)
+		if !result.More || len(result.Alerts) == 0 {
+			return nil
+		}
+	}
+}
+
+// api returns a PagerDuty client bound to the provider's configuration.
+func (p *PagerDutyProvider) api() *common.Client {
+	c := common.NewClient(p.client, p.cfg.APIURL, p.cfg.APIToken)
+	c.From = p.cfg.FromEmail
+	c.Retry = p.cfg.Retry
+	return c
+}
+
+// wrapNotFound names the alert or incident in 404 errors. The result still
+// matches common.ErrNotFound and the underlying *common.APIError.
+func wrapNotFound(id string, err error) error {
+	if errors.Is(err, common.ErrNotFound) {
+		return fmt.Errorf("alert %s not found: %w", id, err)
+	}
+	return err
+}
+
+func parseConfig(cfg map[string]any) Config {
+	out := Config{
+		Source: "pagerduty",
+		APIURL: "https://api.pagerduty.com",
+	}
+	if v, ok := cfg["source"].(string); ok && v != "" {
+		out.Source = v
+	}
+	if v, ok := cfg["apiToken"].(string); ok {
+		out.APIToken = strings.TrimSpace(v)
+	}
+	if v, ok := cfg["apiURL"].(string); ok && v != "" {
+		out.APIURL = strings.TrimSpace(v)
+	}
+	if v, ok := cfg["fromEmail"].(string); ok {
+		out.FromEmail = strings.TrimSpace(v)
+	}
+	if v, ok := common.IntFromConfig(cfg["rateLimitPerMinute"]); ok && v > 0 {
+		out.RateLimitPerMinute = v
+	}
+	out.Retry = common.ParseRetryPolicy(cfg)
+	out.Lookup = common.ParseLookupOptions(cfg)
+	if v, ok := common.IntFromConfig(cfg["queryMaxIncidents"]); ok && v > 0 {
+		out.QueryMaxIncidents = v
+	}
+	return out
+}
+
+func (c Config) queryMaxIncidents() int {
+	if c.QueryMaxIncidents > 0 {
+		return c.QueryMaxIncidents
+	}
+	return defaultQueryMaxIncidents
+}
+
+// pdAlert represents a PagerDuty alert from the API.
+type pdAlert struct {
+	ID         string `json:"id"`
+	Summary    string `json:"summary"`
+	Status     string `json:"status"`
+	Severity   string `json:"severity"`
+	AlertKey   string `json:"alert_key"`
+	HTMLURL    string `json:"html_url"`
+	CreatedAt  string `json:"created_at"`
+	Suppressed bool   `json:"suppressed"`
+	Service    struct {
+		ID      string `json:"id"`
+		Summary string `json:"summary"`
+	} `json:"service"`
+	Body struct {
+		Details any `json:"details"`
+	} `json:"body"`
+}
+
+func convertPDAlert(a pdAlert, incidentID string, source string) schema.Alert {
+	alert := schema.Alert{
+		ID:       incidentID + "/" + a.ID,
+		Title:    a.Summary,
+		Status:   mapPDStatusToOpsOrch(a.Status),
+		Severity: mapPDSeverityToOpsOrch(a.Severity),
+		Service:  a.Service.Summary,
+		Metadata: map[string]any{
+			"source":      source,
+			"severity":    a.Severity,
+			"alert_id":    a.ID,
+			"incident_id": incidentID,
+			"alert_key":   a.AlertKey,
+			"service_id":  a.Service.ID,
+			"html_url":    a.HTMLURL,
+			"suppressed":  a.Suppressed,
+		},
+	}
+
+	switch details := a.Body.Details.(type) {
+	case string:
+		alert.Description = details
+	case map[string]any:
+		alert.Metadata["details"] = details
+	}
+
+	if createdAt, err := time.Parse(time.RFC3339, a.CreatedAt); err == nil {
+		alert.CreatedAt = createdAt
+		alert.UpdatedAt = createdAt
+	}
+
+	return alert
+}
+
+func splitAlertID(id string) (string, string, error) {
+	incidentID, alertID, ok := strings.Cut(id, "/")
+	if !ok || incidentID == "" || alertID == "" {
+		return "", "", common.Invalid(fmt.Errorf("alert id %q must have the form <incident id>/<alert id>", id))
+	}
+	return incidentID, alertID, nil
+}
+
+func onlyOpen(statuses []string) bool {
+	if len(statuses) == 0 {
+		return false
+	}
+	for _, s := range statuses {
+		if mapStatusToPD(s) != "triggered" {
+			return false
+		}
+	}
+	return true
+}
+
+// mapStatusToPD maps OpsOrch alert status to PagerDuty alert status.
+func mapStatusToPD(status string) string {
+	switch strings.ToLower(status) {
+	case "open", "firing", "triggered", "active":
+		return "triggered"
+	case "resolved", "closed":
+		return "resolved"
+	default:
+		return status
+	}
+}
+
+// mapSeverityToPD maps an OpsOrch severity to the PagerDuty alert severity
+// (critical, error, warning or info), using the same table as the Events API
+// create mode. Unknown values are compared as given.
+func mapSeverityToPD(severity string) string {
+	switch s := strings.ToLower(strings.TrimSpace(severity)); s {
+	case "critical", "sev1", "p1":
+		return "critical"
+	case "high", "sev2", "p2", "error":
+		return "error"
+	case "medium", "sev3", "p3", "warning":
+		return "warning"
+	case "low", "sev4", "p4", "info":
+		return "info"
+	default:
+		return s
+	}
+}
+
+// mapPDSeverityToOpsOrch maps a PagerDuty alert severity back to the OpsOrch
+// severity mapSeverityToPD turns into it. Unknown values are returned as given.
+func mapPDSeverityToOpsOrch(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return "critical"
+	case "error":
+		return "high"
+	case "warning":
+		return "medium"
+	case "info":
+		return "low"
+	default:
+		return severity
+	}
+}
+
+// mapPDStatusToOpsOrch maps PagerDuty alert status to OpsOrch alert status.
+func mapPDStatusToOpsOrch(status string) string {
+	switch strings.ToLower(status) {
+	case "triggered":
+		return "open"
+	case "resolved":
+		return "resolved"
+	default:
+		return status
+	}
+}
@@ -0,0 +1,306 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+func TestParseConfigDefaults(t *testing.T) {
+	cfg := parseConfig(map[string]any{})
+	if cfg.Source != "pagerduty" {
+		t.Fatalf("expected default source, got %q", cfg.Source)
+	}
+	if cfg.APIURL != "https://api.pagerduty.com" {
+		t.Fatalf("expected default API URL, got %q", cfg.APIURL)
+	}
+}
+
+func TestNewRequiresCredentials(t *testing.T) {
+	if _, err := New(map[string]any{}); err == nil {
+		t.Fatalf("expected error when apiToken missing")
+	}
+	if _, err := New(map[string]any{"apiToken": "token"}); err != nil {
+		t.Fatalf("expected success with apiToken, got: %v", err)
+	}
+}
+
+func alertJSON(id, status, severity string) map[string]any {
+	return map[string]any{
+		"id":         id,
+		"summary":    "Alert " + id,
+		"status":     status,
+		"severity":   severity,
+		"alert_key":  "key-" + id,
+		"created_at": "2025-11-21T10:00:00Z",
+		"service":    map[string]any{"id": "SVC1", "summary": "Checkout"},
+		"body":       map[string]any{"details": "disk full"},
+	}
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/services":
+			json.NewEncoder(w).Encode(map[string]any{
+				"services": []map[string]any{{"id": "SVC1", "name": "checkout"}},
+			})
+		case r.URL.Path == "/incidents" && r.Method == "GET":
+			json.NewEncoder(w).Encode(map[string]any{
+				"incidents": []map[string]any{{"id": "INC1"}, {"id": "INC2"}},
+			})
+		case r.URL.Path == "/incidents/INC1/alerts" && r.Method == "GET":
+			json.NewEncoder(w).Encode(map[string]any{
+				"alerts": []map[string]any{
+					alertJSON("A1", "triggered", "critical"),
+					alertJSON("A2", "resolved", "warning"),
+				},
+			})
+		case r.URL.Path == "/incidents/INC2/alerts" && r.Method == "GET":
+			json.NewEncoder(w).Encode(map[string]any{
+				"alerts": []map[string]any{alertJSON("A3", "triggered", "warning")},
+			})
+		case r.URL.Path == "/incidents/INC1/alerts/A1" && r.Method == "GET":
+			json.NewEncoder(w).Encode(map[string]any{"alert": alertJSON("A1", "triggered", "critical")})
+		case r.URL.Path == "/incidents/INC1/alerts/A1" && r.Method == "PUT":
+			if r.Header.Get("From") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var body struct {
+				Alert struct {
+					Status string `json:"status"`
+				} `json:"alert"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Alert.Status != "resolved" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"alert": alertJSON("A1", "resolved", "critical")})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestQuery(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{Source: "pagerduty", APIToken: "token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+	ctx := context.Background()
+
+	t.Run("across incidents", func(t *testing.T) {
+		alerts, err := p.Query(ctx, schema.AlertQuery{})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if len(alerts) != 3 {
+			t.Fatalf("len(alerts) = %d, want 3", len(alerts))
+		}
+		if alerts[0].ID != "INC1/A1" {
+			t.Errorf("ID = %s, want INC1/A1", alerts[0].ID)
+		}
+		if alerts[0].Status != "open" || alerts[1].Status != "resolved" {
+			t.Errorf("unexpected statuses %s, %s", alerts[0].Status, alerts[1].Status)
+		}
+		if alerts[0].Description != "disk full" {
+			t.Errorf("Description = %q, want disk full", alerts[0].Description)
+		}
+		if alerts[0].Metadata["incident_id"] != "INC1" {
+			t.Errorf("incident_id = %v, want INC1", alerts[0].Metadata["incident_id"])
+		}
+		if alerts[1].Severity != "medium" || alerts[1].Metadata["severity"] != "warning" {
+			t.Errorf("severity = %q (%v), want medium (warning)", alerts[1].Severity, alerts[1].Metadata["severity"])
+		}
+	})
+
+	t.Run("severity filter and limit", func(t *testing.T) {
+		alerts, err := p.Query(ctx, schema.AlertQuery{Severities: []string{"warning"}, Limit: 1})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if len(alerts) != 1 || alerts[0].ID != "INC1/A2" {
+			t.Fatalf("unexpected alerts %+v", alerts)
+		}
+	})
+
+	t.Run("opsorch severity", func(t *testing.T) {
+		alerts, err := p.Query(ctx, schema.AlertQuery{Severities: []string{"medium"}})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if len(alerts) != 2 || alerts[0].ID != "INC1/A2" || alerts[1].ID != "INC2/A3" {
+			t.Fatalf("medium should match warning alerts, got %+v", alerts)
+		}
+	})
+
+	t.Run("unmatched scope", func(t *testing.T) {
+		exact := &PagerDutyProvider{cfg: p.cfg, client: p.client}
+		exact.cfg.Lookup.Mode = common.MatchExact
+		alerts, err := exact.Query(ctx, schema.AlertQuery{Scope: schema.QueryScope{Service: "Checkout"}})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if len(alerts) != 0 {
+			t.Fatalf("an unmatched service must not widen the query, got %+v", alerts)
+		}
+	})
+
+	t.Run("single incident via metadata", func(t *testing.T) {
+		alerts, err := p.Query(ctx, schema.AlertQuery{Metadata: map[string]any{"incident_id": "INC2"}})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if len(alerts) != 1 || alerts[0].ID != "INC2/A3" {
+			t.Fatalf("unexpected alerts %+v", alerts)
+		}
+	})
+}
+
+func TestQueryStopsAtLimit(t *testing.T) {
+	incidentPages, alertRequests := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/incidents":
+			incidentPages++
+			incidents := make([]map[string]any, 100)
+			for i := range incidents {
+				incidents[i] = map[string]any{"id": fmt.Sprintf("INC%d", incidentPages*100+i)}
+			}
+			json.NewEncoder(w).Encode(map[string]any{"incidents": incidents, "more": true})
+		case strings.HasSuffix(r.URL.Path, "/alerts"):
+			alertRequests++
+			json.NewEncoder(w).Encode(map[string]any{
+				"alerts": []map[string]any{alertJSON("A1", "triggered", "critical")},
+				"more":   true,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{cfg: Config{APIToken: "token", APIURL: server.URL}, client: &http.Client{}}
+	alerts, err := p.Query(context.Background(), schema.AlertQuery{Limit: 1})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("len(alerts) = %d, want 1", len(alerts))
+	}
+	if incidentPages != 1 || alertRequests != 1 {
+		t.Errorf("requests: %d incident pages, %d alert pages; want 1 and 1", incidentPages, alertRequests)
+	}
+}
+
+func TestQueryCapsIncidents(t *testing.T) {
+	alertRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/incidents":
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			incidents := make([]map[string]any, 100)
+			for i := range incidents {
+				incidents[i] = map[string]any{"id": fmt.Sprintf("INC%d", offset+i)}
+			}
+			json.NewEncoder(w).Encode(map[string]any{"incidents": incidents, "more": true})
+		case strings.HasSuffix(r.URL.Path, "/alerts"):
+			alertRequests++
+			json.NewEncoder(w).Encode(map[string]any{
+				"alerts": []map[string]any{alertJSON("A1", "triggered", "info")},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{cfg: Config{APIToken: "token", APIURL: server.URL}, client: &http.Client{}}
+	ctx := context.Background()
+
+	// A filter that never matches stops at the default cap.
+	alerts, err := p.Query(ctx, schema.AlertQuery{Severities: []string{"critical"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 0 || alertRequests != defaultQueryMaxIncidents {
+		t.Errorf("got %d alerts after %d alert requests, want 0 after %d", len(alerts), alertRequests, defaultQueryMaxIncidents)
+	}
+
+	// Without a limit every alert is kept until the configured cap.
+	alertRequests = 0
+	p.cfg = parseConfig(map[string]any{"apiToken": "token", "apiURL": server.URL, "queryMaxIncidents": float64(150)})
+	alerts, err = p.Query(ctx, schema.AlertQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(alerts) != 150 || alertRequests != 150 {
+		t.Errorf("got %d alerts after %d alert requests, want 150", len(alerts), alertRequests)
+	}
+}
+
+func TestGetAndResolve(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{Source: "pagerduty", APIToken: "token", APIURL: server.URL, FromEmail: "user@example.com"},
+		client: &http.Client{},
+	}
+	ctx := context.Background()
+
+	alert, err := p.Get(ctx, "INC1/A1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if alert.Severity != "critical" || alert.Service != "Checkout" {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+
+	resolved, err := p.Resolve(ctx, "INC1/A1")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if resolved.Status != "resolved" {
+		t.Errorf("Status = %s, want resolved", resolved.Status)
+	}
+
+	if _, err := p.Get(ctx, "INC1/MISSING"); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+	if _, err := p.Get(ctx, "A1"); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("Get() error = %v, want ErrValidation", err)
+	}
+}
+
+func TestListIncidentAlerts(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{APIToken: "token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+
+	alerts, err := p.ListIncidentAlerts(context.Background(), "INC1")
+	if err != nil {
+		t.Fatalf("ListIncidentAlerts() error = %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Errorf("len(alerts) = %d, want 2", len(alerts))
+	}
+}
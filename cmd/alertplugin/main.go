@@ -0,0 +1,173 @@
+package main
+
+// The alert plugin adapts the in-process PagerDuty alert provider to OpsOrch
+// Core's JSON-RPC plugin contract. Core spawns this binary locally, writes
+// request objects (method/config/payload) to stdin, and reads responses from
+// stdout. The provider is constructed lazily from the first request's config
+// and reused for subsequent calls.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	corealert "github.com/opsorch/opsorch-core/alert"
+	"github.com/opsorch/opsorch-core/schema"
+	adapter "github.com/opsorch/opsorch-pagerduty-adapter/alert"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+type rpcRequest struct {
+	Method  string          `json:"method"`
+	Config  map[string]any  `json:"config"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type rpcResponse struct {
+	Result   any            `json:"result,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Code     string         `json:"code,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// incidentAlertLister is implemented by providers that can list the alerts of
+// a single incident.
+type incidentAlertLister interface {
+	ListIncidentAlerts(ctx context.Context, incidentID string) ([]schema.Alert, error)
+}
+
+// alertResolver is implemented by providers that can resolve alerts.
+type alertResolver interface {
+	Resolve(ctx context.Context, id string) (schema.Alert, error)
+}
+
+// budgetReporter is implemented by providers that pace requests client-side.
+type budgetReporter interface {
+	RateLimitBudget() (common.RateLimitBudget, bool)
+}
+
+var provider corealert.Provider
+
+func main() {
+	run(os.Stdin, os.Stdout)
+}
+
+func run(r io.Reader, w io.Writer) {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+
+	for {
+		var req rpcRequest
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			writeErr(enc, common.Invalid(err))
+			return
+		}
+
+		prov, err := ensureProvider(req.Config)
+		if err != nil {
+			writeErr(enc, common.Invalid(err))
+			continue
+		}
+
+		ctx := context.Background()
+		switch req.Method {
+		case "alert.query":
+			var query schema.AlertQuery
+			if len(req.Payload) > 0 {
+				if err := json.Unmarshal(req.Payload, &query); err != nil {
+					writeErr(enc, common.Invalid(err))
+					continue
+				}
+			}
+			res, err := prov.Query(ctx, query)
+			write(enc, res, err)
+		case "alert.get":
+			var payload struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(enc, common.Invalid(err))
+				continue
+			}
+			res, err := prov.Get(ctx, payload.ID)
+			write(enc, res, err)
+		case "alert.incident.list":
+			var payload struct {
+				IncidentID string `json:"incidentId"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(enc, common.Invalid(err))
+				continue
+			}
+			lister, ok := prov.(incidentAlertLister)
+			if !ok {
+				writeErr(enc, common.Invalid(fmt.Errorf("unsupported method: %s", req.Method)))
+				continue
+			}
+			res, err := lister.ListIncidentAlerts(ctx, payload.IncidentID)
+			write(enc, res, err)
+		case "alert.resolve":
+			var payload struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(enc, common.Invalid(err))
+				continue
+			}
+			resolver, ok := prov.(alertResolver)
+			if !ok {
+				writeErr(enc, common.Invalid(fmt.Errorf("unsupported method: %s", req.Method)))
+				continue
+			}
+			res, err := resolver.Resolve(ctx, payload.ID)
+			write(enc, res, err)
+		default:
+			writeErr(enc, common.Invalid(fmt.Errorf("unknown method: %s", req.Method)))
+		}
+	}
+}
+
+func ensureProvider(cfg map[string]any) (corealert.Provider, error) {
+	if provider != nil {
+		return provider, nil
+	}
+	prov, err := adapter.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	provider = prov
+	return provider, nil
+}
+
+func write(enc *json.Encoder, result any, err error) {
+	if err != nil {
+		writeErr(enc, err)
+		return
+	}
+	_ = enc.Encode(rpcResponse{Result: result, Metadata: responseMetadata()})
+}
+
+// writeErr sends the error message together with a machine-readable code.
+func writeErr(enc *json.Encoder, err error) {
+	_ = enc.Encode(rpcResponse{Error: err.Error(), Code: common.ErrorCode(err), Metadata: responseMetadata()})
+}
+
+// responseMetadata exposes the current rate limit budget so Core can back off
+// before PagerDuty starts rejecting requests.
+func responseMetadata() map[string]any {
+	reporter, ok := provider.(budgetReporter)
+	if !ok {
+		return nil
+	}
+	budget, ok := reporter.RateLimitBudget()
+	if !ok {
+		return nil
+	}
+	return map[string]any{"rateLimit": budget}
+}
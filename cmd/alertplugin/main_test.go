@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+type stubProvider struct{}
+
+func (stubProvider) Query(ctx context.Context, query schema.AlertQuery) ([]schema.Alert, error) {
+	return []schema.Alert{{ID: "INC1/A1"}}, nil
+}
+func (stubProvider) Get(ctx context.Context, id string) (schema.Alert, error) {
+	return schema.Alert{ID: id}, nil
+}
+func (stubProvider) Resolve(ctx context.Context, id string) (schema.Alert, error) {
+	return schema.Alert{ID: id, Status: "resolved"}, nil
+}
+
+func TestEnsureProviderReturnsExisting(t *testing.T) {
+	t.Cleanup(func() { provider = nil })
+	existing := stubProvider{}
+	provider = existing
+
+	got, err := ensureProvider(map[string]any{"source": "ignored"})
+	if err != nil {
+		t.Fatalf("ensureProvider returned error: %v", err)
+	}
+	if got != existing {
+		t.Fatalf("expected existing provider to be reused")
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Cleanup(func() { provider = nil })
+	provider = stubProvider{}
+
+	var input bytes.Buffer
+	for _, req := range []map[string]any{
+		{"method": "alert.query", "payload": map[string]any{}},
+		{"method": "alert.resolve", "payload": map[string]any{"id": "INC1/A1"}},
+		{"method": "alert.incident.list", "payload": map[string]any{"incidentId": "INC1"}},
+	} {
+		reqBytes, _ := json.Marshal(req)
+		input.Write(reqBytes)
+	}
+
+	var output bytes.Buffer
+	run(&input, &output)
+
+	dec := json.NewDecoder(&output)
+
+	var queryResp struct {
+		Result []map[string]any `json:"result"`
+		Error  string           `json:"error"`
+	}
+	if err := dec.Decode(&queryResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if queryResp.Error != "" || len(queryResp.Result) != 1 {
+		t.Fatalf("unexpected query response %+v", queryResp)
+	}
+
+	var resolveResp struct {
+		Result map[string]any `json:"result"`
+		Error  string         `json:"error"`
+	}
+	if err := dec.Decode(&resolveResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resolveResp.Result["status"] != "resolved" {
+		t.Errorf("expected resolved alert, got %+v", resolveResp)
+	}
+
+	// stubProvider does not list incident alerts.
+	var listResp struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := dec.Decode(&listResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if listResp.Error == "" || listResp.Code != "validation" {
+		t.Errorf("expected unsupported method error, got %+v", listResp)
+	}
+}
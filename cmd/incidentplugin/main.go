@@ -17,6 +17,7 @@ import (
 
 	coreincident "github.com/opsorch/opsorch-core/incident"
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
 	adapter "github.com/opsorch/opsorch-pagerduty-adapter/incident"
 )
 
@@ -27,8 +28,36 @@ type rpcRequest struct {
 }
 
 type rpcResponse struct {
-	Result any    `json:"result,omitempty"`
-	Error  string `json:"error,omitempty"`
+	Result   any            `json:"result,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Code     string         `json:"code,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// budgetReporter is implemented by providers that pace requests client-side.
+type budgetReporter interface {
+	RateLimitBudget() (common.RateLimitBudget, bool)
+}
+
+// cacheInvalidator is implemented by providers that cache name lookups.
+type cacheInvalidator interface {
+	InvalidateLookupCache(kind, name string) int
+}
+
+// timelineFilterer is implemented by providers that filter timelines by kind.
+type timelineFilterer interface {
+	GetTimelineByKind(ctx context.Context, id string, kinds []string) ([]schema.TimelineEntry, error)
+}
+
+// merger is implemented by providers that merge incidents.
+type merger interface {
+	Merge(ctx context.Context, id string, sourceIDs []string) (schema.Incident, error)
+}
+
+// subscriberLister is implemented by providers that expose status update
+// subscribers.
+type subscriberLister interface {
+	Subscribers(ctx context.Context, id string) ([]adapter.Subscriber, error)
 }
 
 var provider coreincident.Provider
@@ -47,13 +76,13 @@ func run(r io.Reader, w io.Writer) {
 			if errors.Is(err, io.EOF) {
 				return
 			}
-			writeErr(enc, err)
+			writeErr(enc, common.Invalid(err))
 			return
 		}
 
 		prov, err := ensureProvider(req.Config)
 		if err != nil {
-			writeErr(enc, err)
+			writeErr(enc, common.Invalid(err))
 			continue
 		}
 
@@ -62,7 +91,7 @@ func run(r io.Reader, w io.Writer) {
 		case "incident.query":
 			var query schema.IncidentQuery
 			if err := json.Unmarshal(req.Payload, &query); err != nil {
-				writeErr(enc, err)
+				writeErr(enc, common.Invalid(err))
 				continue
 			}
 			res, err := prov.Query(ctx, query)
@@ -72,7 +101,7 @@ func run(r io.Reader, w io.Writer) {
 				ID string `json:"id"`
 			}
 			if err := json.Unmarshal(req.Payload, &payload); err != nil {
-				writeErr(enc, err)
+				writeErr(enc, common.Invalid(err))
 				continue
 			}
 			res, err := prov.Get(ctx, payload.ID)
@@ -80,7 +109,7 @@ func run(r io.Reader, w io.Writer) {
 		case "incident.create":
 			var in schema.CreateIncidentInput
 			if err := json.Unmarshal(req.Payload, &in); err != nil {
-				writeErr(enc, err)
+				writeErr(enc, common.Invalid(err))
 				continue
 			}
 			res, err := prov.Create(ctx, in)
@@ -91,20 +120,31 @@ func run(r io.Reader, w io.Writer) {
 				Input schema.UpdateIncidentInput `json:"input"`
 			}
 			if err := json.Unmarshal(req.Payload, &payload); err != nil {
-				writeErr(enc, err)
+				writeErr(enc, common.Invalid(err))
 				continue
 			}
 			res, err := prov.Update(ctx, payload.ID, payload.Input)
 			write(enc, res, err)
 		case "incident.timeline.get":
 			var payload struct {
-				ID string `json:"id"`
+				ID    string   `json:"id"`
+				Kinds []string `json:"kinds"`
 			}
 			if err := json.Unmarshal(req.Payload, &payload); err != nil {
-				writeErr(enc, err)
+				writeErr(enc, common.Invalid(err))
+				continue
+			}
+			if len(payload.Kinds) == 0 {
+				res, err := prov.GetTimeline(ctx, payload.ID)
+				write(enc, res, err)
+				continue
+			}
+			filterer, ok := prov.(timelineFilterer)
+			if !ok {
+				writeErr(enc, common.Invalid(errors.New("timeline kind filter is not supported")))
 				continue
 			}
-			res, err := prov.GetTimeline(ctx, payload.ID)
+			res, err := filterer.GetTimelineByKind(ctx, payload.ID, payload.Kinds)
 			write(enc, res, err)
 		case "incident.timeline.append":
 			var payload struct {
@@ -112,13 +152,60 @@ func run(r io.Reader, w io.Writer) {
 				Input schema.TimelineAppendInput `json:"input"`
 			}
 			if err := json.Unmarshal(req.Payload, &payload); err != nil {
-				writeErr(enc, err)
+				writeErr(enc, common.Invalid(err))
 				continue
 			}
 			err := prov.AppendTimeline(ctx, payload.ID, payload.Input)
 			write(enc, map[string]string{"status": "ok"}, err)
+		case "incident.merge":
+			var payload struct {
+				ID        string   `json:"id"`
+				SourceIDs []string `json:"sourceIds"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(enc, common.Invalid(err))
+				continue
+			}
+			m, ok := prov.(merger)
+			if !ok {
+				writeErr(enc, common.Invalid(errors.New("merge is not supported")))
+				continue
+			}
+			res, err := m.Merge(ctx, payload.ID, payload.SourceIDs)
+			write(enc, res, err)
+		case "incident.subscribers.list":
+			var payload struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(enc, common.Invalid(err))
+				continue
+			}
+			lister, ok := prov.(subscriberLister)
+			if !ok {
+				writeErr(enc, common.Invalid(errors.New("subscribers are not supported")))
+				continue
+			}
+			res, err := lister.Subscribers(ctx, payload.ID)
+			write(enc, res, err)
+		case "lookup.cache.invalidate":
+			var payload struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			}
+			if len(req.Payload) > 0 {
+				if err := json.Unmarshal(req.Payload, &payload); err != nil {
+					writeErr(enc, common.Invalid(err))
+					continue
+				}
+			}
+			removed := 0
+			if inv, ok := prov.(cacheInvalidator); ok {
+				removed = inv.InvalidateLookupCache(payload.Kind, payload.Name)
+			}
+			write(enc, map[string]int{"removed": removed}, nil)
 		default:
-			writeErr(enc, fmt.Errorf("unknown method: %s", req.Method))
+			writeErr(enc, common.Invalid(fmt.Errorf("unknown method: %s", req.Method)))
 		}
 	}
 }
@@ -140,9 +227,26 @@ func write(enc *json.Encoder, result any, err error) {
 		writeErr(enc, err)
 		return
 	}
-	_ = enc.Encode(rpcResponse{Result: result})
+	_ = enc.Encode(rpcResponse{Result: result, Metadata: responseMetadata()})
 }
 
+// writeErr sends the error message together with a machine-readable code
+// (not_found, unauthorized, forbidden, validation, rate_limited,
+// upstream_unavailable or internal).
 func writeErr(enc *json.Encoder, err error) {
-	_ = enc.Encode(rpcResponse{Error: err.Error()})
+	_ = enc.Encode(rpcResponse{Error: err.Error(), Code: common.ErrorCode(err), Metadata: responseMetadata()})
+}
+
+// responseMetadata exposes the current rate limit budget so Core can back off
+// before PagerDuty starts rejecting requests.
+func responseMetadata() map[string]any {
+	reporter, ok := provider.(budgetReporter)
+	if !ok {
+		return nil
+	}
+	budget, ok := reporter.RateLimitBudget()
+	if !ok {
+		return nil
+	}
+	return map[string]any{"rateLimit": budget}
 }
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"net/http"
 	"testing"
 
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+	adapter "github.com/opsorch/opsorch-pagerduty-adapter/incident"
 )
 
 type stubProvider struct{}
@@ -110,3 +113,233 @@ func TestRun(t *testing.T) {
 		t.Errorf("Expected nil result from stub, got %v", resp.Result)
 	}
 }
+
+type notFoundProvider struct{ stubProvider }
+
+func (notFoundProvider) Get(ctx context.Context, id string) (schema.Incident, error) {
+	return schema.Incident{}, &common.APIError{StatusCode: http.StatusNotFound, Message: "Not Found", Code: 2100}
+}
+
+func TestRunReportsErrorCode(t *testing.T) {
+	t.Cleanup(func() { provider = nil })
+	provider = notFoundProvider{}
+
+	var input bytes.Buffer
+	for _, req := range []map[string]any{
+		{"method": "incident.get", "payload": map[string]any{"id": "MISSING"}},
+		{"method": "incident.unknown"},
+	} {
+		reqBytes, _ := json.Marshal(req)
+		input.Write(reqBytes)
+	}
+
+	var output bytes.Buffer
+	run(&input, &output)
+
+	dec := json.NewDecoder(&output)
+	for _, want := range []string{common.CodeNotFound, common.CodeValidation} {
+		var resp struct {
+			Error string `json:"error"`
+			Code  string `json:"code"`
+		}
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Error == "" {
+			t.Errorf("expected error message for code %s", want)
+		}
+		if resp.Code != want {
+			t.Errorf("code = %q, want %q", resp.Code, want)
+		}
+	}
+}
+
+type cachingProvider struct {
+	stubProvider
+	kind, name string
+}
+
+func (p *cachingProvider) InvalidateLookupCache(kind, name string) int {
+	p.kind, p.name = kind, name
+	return 2
+}
+
+func TestRunInvalidatesLookupCache(t *testing.T) {
+	t.Cleanup(func() { provider = nil })
+	stub := &cachingProvider{}
+	provider = stub
+
+	reqBytes, _ := json.Marshal(map[string]any{
+		"method":  "lookup.cache.invalidate",
+		"payload": map[string]any{"kind": "services", "name": "Payments"},
+	})
+	var output bytes.Buffer
+	run(bytes.NewBuffer(reqBytes), &output)
+
+	var resp struct {
+		Result map[string]int `json:"result"`
+		Error  string         `json:"error"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("Plugin returned error: %s", resp.Error)
+	}
+	if resp.Result["removed"] != 2 {
+		t.Errorf("removed = %d, want 2", resp.Result["removed"])
+	}
+	if stub.kind != "services" || stub.name != "Payments" {
+		t.Errorf("invalidated (%q, %q), want (services, Payments)", stub.kind, stub.name)
+	}
+}
+
+type timelineProvider struct {
+	stubProvider
+	kinds []string
+}
+
+func (p *timelineProvider) GetTimelineByKind(ctx context.Context, id string, kinds []string) ([]schema.TimelineEntry, error) {
+	p.kinds = kinds
+	return []schema.TimelineEntry{{ID: "N1", IncidentID: id, Kind: "note"}}, nil
+}
+
+func TestRunFiltersTimelineByKind(t *testing.T) {
+	t.Cleanup(func() { provider = nil })
+	stub := &timelineProvider{}
+	provider = stub
+
+	reqBytes, _ := json.Marshal(map[string]any{
+		"method":  "incident.timeline.get",
+		"payload": map[string]any{"id": "PINC1", "kinds": []string{"note"}},
+	})
+	var output bytes.Buffer
+	run(bytes.NewBuffer(reqBytes), &output)
+
+	var resp struct {
+		Result []schema.TimelineEntry `json:"result"`
+		Error  string                 `json:"error"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("Plugin returned error: %s", resp.Error)
+	}
+	if len(resp.Result) != 1 || resp.Result[0].ID != "N1" {
+		t.Errorf("result = %+v, want the filtered note", resp.Result)
+	}
+	if len(stub.kinds) != 1 || stub.kinds[0] != "note" {
+		t.Errorf("kinds = %v, want [note]", stub.kinds)
+	}
+}
+
+type mergeProvider struct {
+	stubProvider
+	id      string
+	sources []string
+}
+
+func (p *mergeProvider) Merge(ctx context.Context, id string, sourceIDs []string) (schema.Incident, error) {
+	p.id, p.sources = id, sourceIDs
+	return schema.Incident{ID: id}, nil
+}
+
+func TestRunMerge(t *testing.T) {
+	t.Cleanup(func() { provider = nil })
+	stub := &mergeProvider{}
+	provider = stub
+
+	reqBytes, _ := json.Marshal(map[string]any{
+		"method":  "incident.merge",
+		"payload": map[string]any{"id": "PTARGET", "sourceIds": []string{"PSRC1", "PSRC2"}},
+	})
+	var output bytes.Buffer
+	run(bytes.NewBuffer(reqBytes), &output)
+
+	var resp struct {
+		Result schema.Incident `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("Plugin returned error: %s", resp.Error)
+	}
+	if resp.Result.ID != "PTARGET" {
+		t.Errorf("result ID = %q, want PTARGET", resp.Result.ID)
+	}
+	if stub.id != "PTARGET" || len(stub.sources) != 2 || stub.sources[1] != "PSRC2" {
+		t.Errorf("Merge called with (%q, %v)", stub.id, stub.sources)
+	}
+}
+
+type subscriberProvider struct {
+	stubProvider
+	id string
+}
+
+func (p *subscriberProvider) Subscribers(ctx context.Context, id string) ([]adapter.Subscriber, error) {
+	p.id = id
+	return []adapter.Subscriber{
+		{ID: "PUSER1", Type: "user"},
+		{ID: "PTEAM1", Type: "team", Indirect: true, SubscribedVia: []map[string]string{{"id": "PBS1", "type": "business_service"}}},
+	}, nil
+}
+
+func TestRunListsSubscribers(t *testing.T) {
+	t.Cleanup(func() { provider = nil })
+	stub := &subscriberProvider{}
+	provider = stub
+
+	reqBytes, _ := json.Marshal(map[string]any{
+		"method":  "incident.subscribers.list",
+		"payload": map[string]any{"id": "PINC1"},
+	})
+	var output bytes.Buffer
+	run(bytes.NewBuffer(reqBytes), &output)
+
+	var resp struct {
+		Result []map[string]any `json:"result"`
+		Error  string           `json:"error"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("Plugin returned error: %s", resp.Error)
+	}
+	if stub.id != "PINC1" {
+		t.Errorf("Subscribers called with %q, want PINC1", stub.id)
+	}
+	if len(resp.Result) != 2 {
+		t.Fatalf("expected 2 subscribers, got %v", resp.Result)
+	}
+	if first := resp.Result[0]; first["id"] != "PUSER1" || first["type"] != "user" || first["indirect"] != false {
+		t.Errorf("first subscriber = %v", first)
+	}
+	if _, ok := resp.Result[0]["subscribedVia"]; ok {
+		t.Errorf("direct subscriber must omit subscribedVia, got %v", resp.Result[0])
+	}
+	second := resp.Result[1]
+	via, _ := second["subscribedVia"].([]any)
+	if second["indirect"] != true || len(via) != 1 || via[0].(map[string]any)["id"] != "PBS1" {
+		t.Errorf("second subscriber = %v", second)
+	}
+
+	// Providers without subscriber support report a validation error.
+	provider = stubProvider{}
+	output.Reset()
+	run(bytes.NewBuffer(reqBytes), &output)
+	var errResp struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &errResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if errResp.Error == "" || errResp.Code != common.CodeValidation {
+		t.Errorf("expected validation error, got %+v", errResp)
+	}
+}
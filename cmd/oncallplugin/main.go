@@ -0,0 +1,134 @@
+package main
+
+// The on-call plugin exposes PagerDuty on-call responders and schedules over
+// the same JSON-RPC contract as the other plugins. Core spawns this binary
+// locally, writes request objects (method/config/payload) to stdin, and reads
+// responses from stdout. The provider is constructed lazily from the first
+// request's config and reused for subsequent calls.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+	"github.com/opsorch/opsorch-pagerduty-adapter/oncall"
+)
+
+type rpcRequest struct {
+	Method  string          `json:"method"`
+	Config  map[string]any  `json:"config"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type rpcResponse struct {
+	Result   any            `json:"result,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Code     string         `json:"code,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// onCallProvider is the subset of *oncall.PagerDutyProvider used by the plugin.
+type onCallProvider interface {
+	ServiceOnCall(ctx context.Context, q oncall.Query) (oncall.ServiceOnCall, error)
+	Schedules(ctx context.Context, q oncall.ScheduleQuery) ([]oncall.Schedule, error)
+}
+
+// budgetReporter is implemented by providers that pace requests client-side.
+type budgetReporter interface {
+	RateLimitBudget() (common.RateLimitBudget, bool)
+}
+
+var provider onCallProvider
+
+func main() {
+	run(os.Stdin, os.Stdout)
+}
+
+func run(r io.Reader, w io.Writer) {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+
+	for {
+		var req rpcRequest
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			writeErr(enc, common.Invalid(err))
+			return
+		}
+
+		prov, err := ensureProvider(req.Config)
+		if err != nil {
+			writeErr(enc, common.Invalid(err))
+			continue
+		}
+
+		ctx := context.Background()
+		switch req.Method {
+		case "oncall.service.get":
+			var q oncall.Query
+			if err := json.Unmarshal(req.Payload, &q); err != nil {
+				writeErr(enc, common.Invalid(err))
+				continue
+			}
+			res, err := prov.ServiceOnCall(ctx, q)
+			write(enc, res, err)
+		case "oncall.schedules.query":
+			var q oncall.ScheduleQuery
+			if len(req.Payload) > 0 {
+				if err := json.Unmarshal(req.Payload, &q); err != nil {
+					writeErr(enc, common.Invalid(err))
+					continue
+				}
+			}
+			res, err := prov.Schedules(ctx, q)
+			write(enc, res, err)
+		default:
+			writeErr(enc, common.Invalid(fmt.Errorf("unknown method: %s", req.Method)))
+		}
+	}
+}
+
+func ensureProvider(cfg map[string]any) (onCallProvider, error) {
+	if provider != nil {
+		return provider, nil
+	}
+	prov, err := oncall.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	provider = prov
+	return provider, nil
+}
+
+func write(enc *json.Encoder, result any, err error) {
+	if err != nil {
+		writeErr(enc, err)
+		return
+	}
+	_ = enc.Encode(rpcResponse{Result: result, Metadata: responseMetadata()})
+}
+
+// writeErr sends the error message together with a machine-readable code.
+func writeErr(enc *json.Encoder, err error) {
+	_ = enc.Encode(rpcResponse{Error: err.Error(), Code: common.ErrorCode(err), Metadata: responseMetadata()})
+}
+
+// responseMetadata exposes the current rate limit budget so Core can back off
+// before PagerDuty starts rejecting requests.
+func responseMetadata() map[string]any {
+	reporter, ok := provider.(budgetReporter)
+	if !ok {
+		return nil
+	}
+	budget, ok := reporter.RateLimitBudget()
+	if !ok {
+		return nil
+	}
+	return map[string]any{"rateLimit": budget}
+}
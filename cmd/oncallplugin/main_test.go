@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/opsorch/opsorch-pagerduty-adapter/oncall"
+)
+
+type stubProvider struct{}
+
+func (stubProvider) ServiceOnCall(ctx context.Context, q oncall.Query) (oncall.ServiceOnCall, error) {
+	return oncall.ServiceOnCall{ServiceID: q.ServiceID, Levels: []oncall.Level{{Level: 1}}}, nil
+}
+func (stubProvider) Schedules(ctx context.Context, q oncall.ScheduleQuery) ([]oncall.Schedule, error) {
+	return []oncall.Schedule{{ID: "SCH1"}}, nil
+}
+
+func TestEnsureProviderCachesNewInstance(t *testing.T) {
+	t.Cleanup(func() { provider = nil })
+
+	first, err := ensureProvider(map[string]any{"apiToken": "token"})
+	if err != nil {
+		t.Fatalf("ensureProvider returned error: %v", err)
+	}
+	second, err := ensureProvider(map[string]any{})
+	if err != nil {
+		t.Fatalf("ensureProvider returned error on second call: %v", err)
+	}
+	if first != second {
+		t.Fatalf("expected provider instance to be cached between calls")
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Cleanup(func() { provider = nil })
+	provider = stubProvider{}
+
+	var input bytes.Buffer
+	for _, req := range []map[string]any{
+		{"method": "oncall.service.get", "payload": map[string]any{"serviceId": "SVC1"}},
+		{"method": "oncall.schedules.query"},
+	} {
+		reqBytes, _ := json.Marshal(req)
+		input.Write(reqBytes)
+	}
+
+	var output bytes.Buffer
+	run(&input, &output)
+
+	dec := json.NewDecoder(&output)
+	var serviceResp struct {
+		Result oncall.ServiceOnCall `json:"result"`
+		Error  string               `json:"error"`
+	}
+	if err := dec.Decode(&serviceResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if serviceResp.Error != "" || serviceResp.Result.ServiceID != "SVC1" {
+		t.Errorf("unexpected response %+v", serviceResp)
+	}
+
+	var scheduleResp struct {
+		Result []oncall.Schedule `json:"result"`
+		Error  string            `json:"error"`
+	}
+	if err := dec.Decode(&scheduleResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if scheduleResp.Error != "" || len(scheduleResp.Result) != 1 {
+		t.Errorf("unexpected response %+v", scheduleResp)
+	}
+}
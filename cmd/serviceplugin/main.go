@@ -11,11 +11,22 @@ import (
 
 	"github.com/opsorch/opsorch-core/schema"
 	coreservice "github.com/opsorch/opsorch-core/service"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
 	"github.com/opsorch/opsorch-pagerduty-adapter/service"
 )
 
 var provider coreservice.Provider
 
+// budgetReporter is implemented by providers that pace requests client-side.
+type budgetReporter interface {
+	RateLimitBudget() (common.RateLimitBudget, bool)
+}
+
+// cacheInvalidator is implemented by providers that cache name lookups.
+type cacheInvalidator interface {
+	InvalidateLookupCache(kind, name string) int
+}
+
 func main() {
 	run(os.Stdin, os.Stdout)
 }
@@ -31,13 +42,13 @@ func run(r io.Reader, w io.Writer) {
 			Payload json.RawMessage `json:"payload"`
 		}
 		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
-			writeError(enc, fmt.Sprintf("parse request: %v", err))
+			writeError(enc, common.Invalid(fmt.Errorf("parse request: %w", err)))
 			continue
 		}
 
 		prov, err := ensureProvider(req.Config)
 		if err != nil {
-			writeError(enc, fmt.Sprintf("init provider: %v", err))
+			writeError(enc, common.Invalid(fmt.Errorf("init provider: %w", err)))
 			continue
 		}
 
@@ -47,24 +58,41 @@ func run(r io.Reader, w io.Writer) {
 			var q schema.ServiceQuery
 			if len(req.Payload) > 0 {
 				if err := json.Unmarshal(req.Payload, &q); err != nil {
-					writeError(enc, fmt.Sprintf("decode query: %v", err))
+					writeError(enc, common.Invalid(fmt.Errorf("decode query: %w", err)))
 					continue
 				}
 			}
 			services, err := prov.Query(ctx, q)
 			if err != nil {
-				writeError(enc, err.Error())
+				writeError(enc, err)
 				continue
 			}
 			writeResult(enc, services)
 
+		case "lookup.cache.invalidate":
+			var payload struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			}
+			if len(req.Payload) > 0 {
+				if err := json.Unmarshal(req.Payload, &payload); err != nil {
+					writeError(enc, common.Invalid(fmt.Errorf("decode payload: %w", err)))
+					continue
+				}
+			}
+			removed := 0
+			if inv, ok := prov.(cacheInvalidator); ok {
+				removed = inv.InvalidateLookupCache(payload.Kind, payload.Name)
+			}
+			writeResult(enc, map[string]int{"removed": removed})
+
 		default:
-			writeError(enc, fmt.Sprintf("unknown method: %s", req.Method))
+			writeError(enc, common.Invalid(fmt.Errorf("unknown method: %s", req.Method)))
 		}
 	}
 
 	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
-		writeError(enc, fmt.Sprintf("scanner error: %v", err))
+		writeError(enc, fmt.Errorf("scanner error: %w", err))
 	}
 }
 
@@ -81,9 +109,32 @@ func ensureProvider(cfg map[string]any) (coreservice.Provider, error) {
 }
 
 func writeResult(enc *json.Encoder, v any) {
-	enc.Encode(map[string]any{"result": v})
+	resp := map[string]any{"result": v}
+	if meta := responseMetadata(); meta != nil {
+		resp["metadata"] = meta
+	}
+	enc.Encode(resp)
+}
+
+// writeError sends the error message together with a machine-readable code.
+func writeError(enc *json.Encoder, err error) {
+	resp := map[string]any{"error": err.Error(), "code": common.ErrorCode(err)}
+	if meta := responseMetadata(); meta != nil {
+		resp["metadata"] = meta
+	}
+	enc.Encode(resp)
 }
 
-func writeError(enc *json.Encoder, msg string) {
-	enc.Encode(map[string]any{"error": msg})
+// responseMetadata exposes the current rate limit budget so Core can back off
+// before PagerDuty starts rejecting requests.
+func responseMetadata() map[string]any {
+	reporter, ok := provider.(budgetReporter)
+	if !ok {
+		return nil
+	}
+	budget, ok := reporter.RateLimitBudget()
+	if !ok {
+		return nil
+	}
+	return map[string]any{"rateLimit": budget}
 }
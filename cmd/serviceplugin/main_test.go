@@ -89,3 +89,49 @@ func TestRunInvalidConfig(t *testing.T) {
 		t.Error("Expected error for missing config, got success")
 	}
 }
+
+func TestRunReportsRateLimitBudget(t *testing.T) {
+	provider = nil
+	t.Cleanup(func() { provider = nil })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"services": []}`))
+	}))
+	defer server.Close()
+
+	req := map[string]any{
+		"method": "service.query",
+		"config": map[string]any{
+			"apiToken":           "budget-token",
+			"apiURL":             server.URL,
+			"rateLimitPerMinute": 120,
+		},
+	}
+	reqBytes, _ := json.Marshal(req)
+	var output bytes.Buffer
+
+	run(bytes.NewBuffer(reqBytes), &output)
+
+	var resp struct {
+		Error    string `json:"error"`
+		Metadata struct {
+			RateLimit struct {
+				LimitPerMinute int `json:"limitPerMinute"`
+				Remaining      int `json:"remaining"`
+			} `json:"rateLimit"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("Plugin returned error: %s", resp.Error)
+	}
+	if resp.Metadata.RateLimit.LimitPerMinute != 120 {
+		t.Errorf("limitPerMinute = %d, want 120", resp.Metadata.RateLimit.LimitPerMinute)
+	}
+	if resp.Metadata.RateLimit.Remaining != 119 {
+		t.Errorf("remaining = %d, want 119", resp.Metadata.RateLimit.Remaining)
+	}
+}
@@ -0,0 +1,196 @@
+package common
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lookup cache defaults.
+const (
+	defaultLookupCacheTTL         = 5 * time.Minute
+	defaultLookupCacheNegativeTTL = 30 * time.Second
+	defaultLookupCacheSize        = 1000
+)
+
+// LookupCacheConfig sizes a LookupCache. A TTL of zero disables caching.
+type LookupCacheConfig struct {
+	TTL         time.Duration // lifetime of names that matched something
+	NegativeTTL time.Duration // lifetime of names that matched nothing
+	MaxEntries  int
+}
+
+// DefaultLookupCacheConfig returns the cache settings used when the adapter
+// config does not override them.
+func DefaultLookupCacheConfig() LookupCacheConfig {
+	return LookupCacheConfig{
+		TTL:         defaultLookupCacheTTL,
+		NegativeTTL: defaultLookupCacheNegativeTTL,
+		MaxEntries:  defaultLookupCacheSize,
+	}
+}
+
+// parseLookupCacheConfig reads lookupCacheTTL, lookupCacheNegativeTTL and
+// lookupCacheSize.
+func parseLookupCacheConfig(cfg map[string]any) LookupCacheConfig {
+	out := DefaultLookupCacheConfig()
+	if v, ok := DurationFromConfig(cfg["lookupCacheTTL"]); ok && v >= 0 {
+		out.TTL = v
+	}
+	if v, ok := DurationFromConfig(cfg["lookupCacheNegativeTTL"]); ok && v >= 0 {
+		out.NegativeTTL = v
+	}
+	if v, ok := IntFromConfig(cfg["lookupCacheSize"]); ok && v > 0 {
+		out.MaxEntries = v
+	}
+	return out
+}
+
+// LookupCache remembers the objects a name lookup matched so repeated Scope
+// filters do not cost extra round-trips. It is safe for concurrent use; a nil
+// *LookupCache caches nothing.
+type LookupCache struct {
+	mu      sync.Mutex
+	cfg     LookupCacheConfig
+	entries map[lookupCacheKey]lookupCacheEntry
+	now     func() time.Time
+}
+
+type lookupCacheKey struct {
+	collection string
+	mode       MatchMode
+	name       string
+}
+
+type lookupCacheEntry struct {
+	matches LookupResults
+	expires time.Time
+}
+
+// NewLookupCache returns a cache with the given settings, or nil when the TTL
+// disables caching.
+func NewLookupCache(cfg LookupCacheConfig) *LookupCache {
+	if cfg.TTL <= 0 {
+		return nil
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultLookupCacheSize
+	}
+	return &LookupCache{
+		cfg:     cfg,
+		entries: map[lookupCacheKey]lookupCacheEntry{},
+		now:     time.Now,
+	}
+}
+
+var (
+	sharedCachesMu sync.Mutex
+	sharedCaches   = map[string]*LookupCache{}
+)
+
+// SharedLookupCache returns the process-wide lookup cache for an API URL and
+// token, creating it on first use, so the providers in one plugin process
+// share their lookups. The settings of the first caller win.
+func SharedLookupCache(apiURL, apiToken string, cfg LookupCacheConfig) *LookupCache {
+	if cfg.TTL <= 0 {
+		return nil
+	}
+	sharedCachesMu.Lock()
+	defer sharedCachesMu.Unlock()
+	key := apiURL + "\x00" + apiToken
+	if c, ok := sharedCaches[key]; ok {
+		return c
+	}
+	c := NewLookupCache(cfg)
+	sharedCaches[key] = c
+	return c
+}
+
+func (c *LookupCache) get(key lookupCacheKey) (LookupResults, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.matches, true
+}
+
+func (c *LookupCache) put(key lookupCacheKey, matches LookupResults) {
+	if c == nil {
+		return
+	}
+	ttl := c.cfg.TTL
+	if len(matches) == 0 {
+		if c.cfg.NegativeTTL <= 0 {
+			return
+		}
+		ttl = c.cfg.NegativeTTL
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.cfg.MaxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = lookupCacheEntry{matches: matches, expires: now.Add(ttl)}
+}
+
+// evict drops expired entries, or the entry closest to expiry if none has
+// expired. Callers must hold c.mu.
+func (c *LookupCache) evict(now time.Time) {
+	var oldest lookupCacheKey
+	var oldestExpiry time.Time
+	removed := false
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			removed = true
+			continue
+		}
+		if oldestExpiry.IsZero() || entry.expires.Before(oldestExpiry) {
+			oldest, oldestExpiry = key, entry.expires
+		}
+	}
+	if !removed && !oldestExpiry.IsZero() {
+		delete(c.entries, oldest)
+	}
+}
+
+// Invalidate removes cached lookups and returns how many were dropped. kind
+// is the object collection ("services", "teams", "users",
+// "escalation_policies", "priorities", or "environments" for environment
+// scopes) and name the looked-up name; empty values match everything.
+func (c *LookupCache) Invalidate(kind, name string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for key := range c.entries {
+		if (kind == "" || key.collection == kind) && (name == "" || strings.EqualFold(key.name, name)) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Len returns the number of cached lookups, including expired ones not yet
+// evicted.
+func (c *LookupCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,128 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLookupCache(t *testing.T) {
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Query().Get("query")]++
+		services := []map[string]any{}
+		if r.URL.Query().Get("query") == "API" {
+			services = append(services,
+				map[string]any{"id": "SVC1", "name": "Production API"},
+				map[string]any{"id": "SVC2", "name": "Staging API"},
+			)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"services": services})
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, server.URL, "token")
+	ctx := context.Background()
+	cache := NewLookupCache(LookupCacheConfig{TTL: time.Minute, NegativeTTL: time.Second, MaxEntries: 10})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	opts := LookupOptions{Cache: cache}
+
+	for i := 0; i < 2; i++ {
+		matches, err := LookupServices(ctx, client, "API", opts)
+		ids := matches.IDs()
+		if err != nil || len(ids) != 2 {
+			t.Fatalf("lookup = %v, %v", ids, err)
+		}
+	}
+	if calls["API"] != 1 {
+		t.Errorf("expected 1 request for a cached name, got %d", calls["API"])
+	}
+
+	strict := opts
+	strict.Strict = true
+	var ambiguous *AmbiguousError
+	if _, err := LookupServices(ctx, client, "API", strict); !errors.As(err, &ambiguous) {
+		t.Errorf("expected AmbiguousError from cached matches, got %v", err)
+	}
+	if calls["API"] != 1 {
+		t.Errorf("strict lookup should reuse the cache, got %d requests", calls["API"])
+	}
+
+	// Misses are cached for the shorter negative TTL.
+	LookupServices(ctx, client, "missing", opts)
+	LookupServices(ctx, client, "missing", opts)
+	if calls["missing"] != 1 {
+		t.Errorf("expected negative result to be cached, got %d requests", calls["missing"])
+	}
+	now = now.Add(2 * time.Second)
+	LookupServices(ctx, client, "missing", opts)
+	if calls["missing"] != 2 {
+		t.Errorf("expected negative entry to expire, got %d requests", calls["missing"])
+	}
+
+	if n := cache.Invalidate("services", "api"); n != 1 {
+		t.Errorf("Invalidate() = %d, want 1", n)
+	}
+	LookupServices(ctx, client, "API", opts)
+	if calls["API"] != 2 {
+		t.Errorf("expected a request after invalidation, got %d", calls["API"])
+	}
+
+	now = now.Add(time.Minute)
+	LookupServices(ctx, client, "API", opts)
+	if calls["API"] != 3 {
+		t.Errorf("expected a request after TTL expiry, got %d", calls["API"])
+	}
+}
+
+func TestLookupCacheEviction(t *testing.T) {
+	cache := NewLookupCache(LookupCacheConfig{TTL: time.Minute, MaxEntries: 2})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	match := LookupResults{{ID: "X"}}
+	for _, name := range []string{"a", "b", "c"} {
+		cache.put(lookupCacheKey{collection: "teams", name: name}, match)
+		now = now.Add(time.Second)
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", cache.Len())
+	}
+	if _, ok := cache.get(lookupCacheKey{collection: "teams", name: "a"}); ok {
+		t.Error("expected the oldest entry to be evicted")
+	}
+	if _, ok := cache.get(lookupCacheKey{collection: "teams", name: "c"}); !ok {
+		t.Error("expected the newest entry to be cached")
+	}
+	if n := cache.Invalidate("", ""); n != 2 {
+		t.Errorf("Invalidate(all) = %d, want 2", n)
+	}
+}
+
+func TestParseLookupCacheConfig(t *testing.T) {
+	if got := ParseLookupOptions(map[string]any{}).CacheConfig; got != DefaultLookupCacheConfig() {
+		t.Errorf("default cache config = %+v", got)
+	}
+	got := ParseLookupOptions(map[string]any{
+		"lookupCacheTTL":         "0s",
+		"lookupCacheNegativeTTL": float64(5000),
+		"lookupCacheSize":        float64(50),
+	}).CacheConfig
+	want := LookupCacheConfig{NegativeTTL: 5 * time.Second, MaxEntries: 50}
+	if got != want {
+		t.Errorf("cache config = %+v, want %+v", got, want)
+	}
+	if SharedLookupCache("https://api.pagerduty.com", "token", got) != nil {
+		t.Error("expected a zero TTL to disable the cache")
+	}
+
+	var nilCache *LookupCache
+	if nilCache.Invalidate("", "") != 0 || nilCache.Len() != 0 {
+		t.Error("nil cache should be empty")
+	}
+}
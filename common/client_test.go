@@ -0,0 +1,99 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClientHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Token token=secret" {
+			t.Errorf("Authorization = %q, want Token token=secret", got)
+		}
+		if got := r.Header.Get("Accept"); got != "application/vnd.pagerduty+json;version=2" {
+			t.Errorf("Accept = %q", got)
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if got := r.Header.Get("From"); got != "" {
+				t.Errorf("From header should not be sent on GET, got %q", got)
+			}
+			if got := r.URL.Query().Get("query"); got != "api" {
+				t.Errorf("query = %q, want api", got)
+			}
+		case http.MethodPost:
+			if got := r.Header.Get("From"); got != "user@example.com" {
+				t.Errorf("From = %q, want user@example.com", got)
+			}
+			if got := r.Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]any{"value": "ok"})
+	}))
+	defer server.Close()
+
+	c := NewClient(&http.Client{}, server.URL+"/", "secret")
+	c.From = "user@example.com"
+	ctx := context.Background()
+
+	var out struct {
+		Value string `json:"value"`
+	}
+	if err := c.Get(ctx, "/services", url.Values{"query": {"api"}}, &out); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if out.Value != "ok" {
+		t.Errorf("Value = %q, want ok", out.Value)
+	}
+	if err := c.Post(ctx, "/incidents", map[string]any{"incident": map[string]any{}}, nil); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+}
+
+func TestClientAPIError(t *testing.T) {
+	t.Run("decodes pagerduty error body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"Invalid Input Provided","code":2001,"errors":["Title cannot be empty."]}}`))
+		}))
+		defer server.Close()
+
+		err := NewClient(&http.Client{}, server.URL, "token").Post(context.Background(), "/incidents", map[string]any{}, nil)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("expected *APIError, got %T (%v)", err, err)
+		}
+		if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != 2001 {
+			t.Errorf("unexpected status/code: %d/%d", apiErr.StatusCode, apiErr.Code)
+		}
+		if apiErr.Message != "Invalid Input Provided" {
+			t.Errorf("Message = %q", apiErr.Message)
+		}
+		if len(apiErr.Errors) != 1 || apiErr.Errors[0] != "Title cannot be empty." {
+			t.Errorf("Errors = %v", apiErr.Errors)
+		}
+	})
+
+	t.Run("keeps raw body when not json", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not here"))
+		}))
+		defer server.Close()
+
+		err := NewClient(&http.Client{}, server.URL, "token").Get(context.Background(), "/incidents/X", nil, nil)
+		if !IsStatus(err, http.StatusNotFound) {
+			t.Fatalf("expected 404 APIError, got %v", err)
+		}
+		if err.Error() != "pagerduty api error: 404 not here" {
+			t.Errorf("Error() = %q", err.Error())
+		}
+	})
+}
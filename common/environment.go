@@ -0,0 +1,225 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxServicePages bounds ListServices so a misbehaving API cannot page forever.
+const maxServicePages = 100
+
+// Environments resolves the environment of a PagerDuty service. PagerDuty has
+// no environment concept, so it is derived, in order of precedence, from a
+// static map keyed by service ID or name, a regular expression applied to the
+// service name, or tags on the service's teams (e.g. "env:prod").
+type Environments struct {
+	// Pattern extracts the environment from a service name. The named group
+	// "env" is used if present, otherwise the first group.
+	Pattern string
+	// Map assigns environments to services by ID or lower-cased name.
+	Map map[string]string
+	// TagPrefix selects team tags such as "env:prod"; the text after the
+	// prefix is the environment.
+	TagPrefix string
+
+	re *regexp.Regexp
+}
+
+// ParseEnvironments reads environmentPattern, environmentMap and
+// environmentTagPrefix from adapter config. Call Compile before use.
+func ParseEnvironments(cfg map[string]any) Environments {
+	var e Environments
+	if v, ok := cfg["environmentPattern"].(string); ok {
+		e.Pattern = strings.TrimSpace(v)
+	}
+	if m, ok := cfg["environmentMap"].(map[string]any); ok {
+		e.Map = make(map[string]string, len(m))
+		for k, v := range m {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+				e.Map[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(s)
+			}
+		}
+	}
+	if v, ok := cfg["environmentTagPrefix"].(string); ok {
+		e.TagPrefix = strings.TrimSpace(v)
+	}
+	return e
+}
+
+// Compile validates the pattern. It must be called before ForService.
+func (e *Environments) Compile() error {
+	if e.Pattern == "" {
+		e.re = nil
+		return nil
+	}
+	re, err := regexp.Compile(e.Pattern)
+	if err != nil {
+		return fmt.Errorf("pagerduty environmentPattern is invalid: %w", err)
+	}
+	if re.NumSubexp() == 0 {
+		return fmt.Errorf("pagerduty environmentPattern %q must contain a capture group", e.Pattern)
+	}
+	e.re = re
+	return nil
+}
+
+// Enabled reports whether any environment source is configured.
+func (e Environments) Enabled() bool {
+	return e.Pattern != "" || len(e.Map) > 0 || e.TagPrefix != ""
+}
+
+// ForService returns the environment of a service, or "" if it has none.
+// teamEnvs maps team IDs to environments (see TeamEnvironments) and may be
+// nil when tag resolution is not needed.
+func (e Environments) ForService(id, name string, teamIDs []string, teamEnvs map[string]string) string {
+	if env, ok := e.Map[strings.ToLower(id)]; ok && id != "" {
+		return env
+	}
+	if env, ok := e.Map[strings.ToLower(name)]; ok && name != "" {
+		return env
+	}
+	if e.re != nil {
+		if m := e.re.FindStringSubmatch(name); m != nil {
+			idx := e.re.SubexpIndex("env")
+			if idx < 0 {
+				idx = 1
+			}
+			if m[idx] != "" {
+				return m[idx]
+			}
+		}
+	}
+	for _, teamID := range teamIDs {
+		if env, ok := teamEnvs[teamID]; ok {
+			return env
+		}
+	}
+	return ""
+}
+
+// TeamEnvironments maps team IDs to environments using tags that start with
+// TagPrefix. It returns nil without calling the API when TagPrefix is unset.
+func (e Environments) TeamEnvironments(ctx context.Context, c *Client) (map[string]string, error) {
+	if e.TagPrefix == "" {
+		return nil, nil
+	}
+	params := url.Values{}
+	params.Set("query", e.TagPrefix)
+	params.Set("limit", "100")
+	var tags struct {
+		Tags []struct {
+			ID    string `json:"id"`
+			Label string `json:"label"`
+		} `json:"tags"`
+	}
+	if err := c.Get(ctx, "/tags", params, &tags); err != nil {
+		return nil, fmt.Errorf("list environment tags: %w", err)
+	}
+
+	out := map[string]string{}
+	for _, tag := range tags.Tags {
+		if !strings.HasPrefix(strings.ToLower(tag.Label), strings.ToLower(e.TagPrefix)) {
+			continue
+		}
+		env := strings.TrimSpace(tag.Label[len(e.TagPrefix):])
+		if env == "" {
+			continue
+		}
+		var teams struct {
+			Teams []struct {
+				ID string `json:"id"`
+			} `json:"teams"`
+		}
+		if err := c.Get(ctx, "/tags/"+tag.ID+"/teams", nil, &teams); err != nil {
+			return nil, fmt.Errorf("list teams tagged %q: %w", tag.Label, err)
+		}
+		for _, team := range teams.Teams {
+			if _, ok := out[team.ID]; !ok {
+				out[team.ID] = env
+			}
+		}
+	}
+	return out, nil
+}
+
+// ServiceRef is the subset of a PagerDuty service needed for scoping.
+type ServiceRef struct {
+	ID      string
+	Name    string
+	TeamIDs []string
+}
+
+// ListServices returns every service visible to the token, following
+// PagerDuty's offset/more pagination.
+func ListServices(ctx context.Context, c *Client) ([]ServiceRef, error) {
+	var out []ServiceRef
+	params := url.Values{}
+	params.Set("limit", "100")
+	for page, offset := 0, 0; page < maxServicePages; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		params.Set("offset", strconv.Itoa(offset))
+		var result struct {
+			Services []struct {
+				ID    string `json:"id"`
+				Name  string `json:"name"`
+				Teams []struct {
+					ID string `json:"id"`
+				} `json:"teams"`
+			} `json:"services"`
+			More bool `json:"more"`
+		}
+		if err := c.Get(ctx, "/services", params, &result); err != nil {
+			return nil, err
+		}
+		for _, svc := range result.Services {
+			ref := ServiceRef{ID: svc.ID, Name: svc.Name}
+			for _, team := range svc.Teams {
+				ref.TeamIDs = append(ref.TeamIDs, team.ID)
+			}
+			out = append(out, ref)
+		}
+		offset += len(result.Services)
+		if !result.More || len(result.Services) == 0 {
+			break
+		}
+	}
+	return out, nil
+}
+
+// environmentsKind is the LookupCache collection holding the services of
+// each environment.
+const environmentsKind = "environments"
+
+// ServiceIDsInEnvironment returns the IDs of services whose environment is env
+// (compared case-insensitively). Resolving an environment lists every
+// service, so the result is kept in cache (which may be nil) for its TTL
+// under the "environments" kind.
+func (e Environments) ServiceIDsInEnvironment(ctx context.Context, c *Client, env string, cache *LookupCache) ([]string, error) {
+	key := lookupCacheKey{collection: environmentsKind, name: strings.ToLower(env)}
+	if matches, ok := cache.get(key); ok {
+		return matches.IDs(), nil
+	}
+
+	teamEnvs, err := e.TeamEnvironments(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	services, err := ListServices(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("list services: %w", err)
+	}
+	var matches LookupResults
+	for _, svc := range services {
+		if strings.EqualFold(e.ForService(svc.ID, svc.Name, svc.TeamIDs, teamEnvs), env) {
+			matches = append(matches, LookupResult{ID: svc.ID, Name: svc.Name})
+		}
+	}
+	cache.put(key, matches)
+	return matches.IDs(), nil
+}
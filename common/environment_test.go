@@ -0,0 +1,113 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestEnvironmentsForService(t *testing.T) {
+	e := ParseEnvironments(map[string]any{
+		"environmentPattern": `-(?P<env>prod|staging)$`,
+		"environmentMap":     map[string]any{"PLEGACY": "prod", "Batch Jobs": "staging"},
+	})
+	if err := e.Compile(); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	tests := []struct {
+		id, name string
+		teamIDs  []string
+		want     string
+	}{
+		{"PSVC1", "payments-prod", nil, "prod"},
+		{"PSVC2", "payments-staging", nil, "staging"},
+		{"PLEGACY", "old-payments", nil, "prod"},
+		{"PSVC3", "batch jobs", nil, "staging"},
+		{"PSVC4", "payments", nil, ""},
+		{"PSVC5", "search", []string{"PTEAM1"}, "dev"},
+	}
+	teamEnvs := map[string]string{"PTEAM1": "dev"}
+	for _, tt := range tests {
+		if got := e.ForService(tt.id, tt.name, tt.teamIDs, teamEnvs); got != tt.want {
+			t.Errorf("ForService(%q, %q) = %q, want %q", tt.id, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEnvironmentsCompile(t *testing.T) {
+	for _, pattern := range []string{"(", "-prod$"} {
+		e := Environments{Pattern: pattern}
+		if err := e.Compile(); err == nil {
+			t.Errorf("Compile(%q) expected error", pattern)
+		}
+	}
+}
+
+func TestServiceIDsInEnvironment(t *testing.T) {
+	var serviceRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tags":
+			json.NewEncoder(w).Encode(map[string]any{
+				"tags": []map[string]any{{"id": "PTAG1", "label": "env:prod"}, {"id": "PTAG2", "label": "envoy"}},
+			})
+		case "/tags/PTAG1/teams":
+			json.NewEncoder(w).Encode(map[string]any{"teams": []map[string]any{{"id": "PTEAM1"}}})
+		case "/services":
+			serviceRequests++
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			if offset == 0 {
+				json.NewEncoder(w).Encode(map[string]any{
+					"services": []map[string]any{
+						{"id": "PSVC1", "name": "payments-prod"},
+						{"id": "PSVC2", "name": "payments-staging"},
+					},
+					"more": true,
+				})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"services": []map[string]any{
+					{"id": "PSVC3", "name": "search", "teams": []map[string]any{{"id": "PTEAM1"}}},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	e := Environments{Pattern: `-(prod|staging)$`, TagPrefix: "env:"}
+	if err := e.Compile(); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	client := NewClient(&http.Client{}, server.URL, "token")
+	cache := NewLookupCache(DefaultLookupCacheConfig())
+	for _, env := range []string{"PROD", "prod"} {
+		ids, err := e.ServiceIDsInEnvironment(context.Background(), client, env, cache)
+		if err != nil {
+			t.Fatalf("ServiceIDsInEnvironment(%q) error = %v", env, err)
+		}
+		if want := []string{"PSVC1", "PSVC3"}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("ServiceIDsInEnvironment(%q) = %v, want %v", env, ids, want)
+		}
+	}
+	if serviceRequests != 2 {
+		t.Errorf("expected the second resolution to be cached, got %d /services requests", serviceRequests)
+	}
+
+	if n := cache.Invalidate("environments", "prod"); n != 1 {
+		t.Errorf("Invalidate() = %d, want 1", n)
+	}
+	if _, err := e.ServiceIDsInEnvironment(context.Background(), client, "prod", nil); err != nil {
+		t.Fatalf("ServiceIDsInEnvironment() error = %v", err)
+	}
+	if serviceRequests != 4 {
+		t.Errorf("expected an uncached resolution to list services again, got %d requests", serviceRequests)
+	}
+}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Sentinel errors mapped to OpsOrch Core error categories. Errors returned by
+// the providers match these with errors.Is; *APIError matches the category
+// that corresponds to its HTTP status.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrValidation   = errors.New("validation failed")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUpstream     = errors.New("pagerduty unavailable")
+)
+
+// Machine-readable error codes sent to Core alongside the error message.
+const (
+	CodeNotFound     = "not_found"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeValidation   = "validation"
+	CodeRateLimited  = "rate_limited"
+	CodeUpstream     = "upstream_unavailable"
+	CodeInternal     = "internal"
+)
+
+// Is maps the HTTP status of a PagerDuty error onto the sentinel errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUpstream:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// invalidError keeps the original message while matching ErrValidation.
+type invalidError struct {
+	err error
+}
+
+func (e invalidError) Error() string { return e.err.Error() }
+
+func (e invalidError) Unwrap() []error { return []error{e.err, ErrValidation} }
+
+// Invalid marks err as a validation failure without changing its message.
+func Invalid(err error) error {
+	if err == nil {
+		return nil
+	}
+	return invalidError{err: err}
+}
+
+// ErrorCode returns the machine-readable code for err.
+func ErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, ErrUnauthorized):
+		return CodeUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return CodeForbidden
+	case errors.Is(err, ErrValidation):
+		return CodeValidation
+	case errors.Is(err, ErrRateLimited):
+		return CodeRateLimited
+	case errors.Is(err, ErrUpstream):
+		return CodeUpstream
+	default:
+		return CodeInternal
+	}
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"not found", &APIError{StatusCode: http.StatusNotFound}, CodeNotFound},
+		{"unauthorized", &APIError{StatusCode: http.StatusUnauthorized}, CodeUnauthorized},
+		{"forbidden", &APIError{StatusCode: http.StatusForbidden}, CodeForbidden},
+		{"bad request", &APIError{StatusCode: http.StatusBadRequest, Code: 2001}, CodeValidation},
+		{"rate limited", &APIError{StatusCode: http.StatusTooManyRequests}, CodeRateLimited},
+		{"upstream", &APIError{StatusCode: http.StatusServiceUnavailable}, CodeUpstream},
+		{"wrapped", fmt.Errorf("incident X not found: %w", &APIError{StatusCode: http.StatusNotFound}), CodeNotFound},
+		{"invalid", Invalid(errors.New("bad payload")), CodeValidation},
+		{"other", errors.New("boom"), CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCode(tt.err); got != tt.want {
+				t.Errorf("ErrorCode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidKeepsMessageAndCause(t *testing.T) {
+	cause := errors.New("unexpected end of JSON input")
+	err := Invalid(cause)
+	if err.Error() != cause.Error() {
+		t.Errorf("Error() = %q, want %q", err.Error(), cause.Error())
+	}
+	if !errors.Is(err, cause) || !errors.Is(err, ErrValidation) {
+		t.Errorf("expected err to match both the cause and ErrValidation")
+	}
+	if Invalid(nil) != nil {
+		t.Errorf("Invalid(nil) should be nil")
+	}
+}
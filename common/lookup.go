@@ -4,102 +4,289 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 )
 
-// LookupServiceIDsByName queries PagerDuty services by name and returns matching service IDs.
-func LookupServiceIDsByName(ctx context.Context, client *http.Client, apiURL, apiToken, name string) ([]string, error) {
-	params := url.Values{}
-	params.Set("query", name)
-	params.Set("limit", "100")
+// Lookup pagination: lookups read pages of lookupPageSize objects, up to
+// defaultLookupMaxPages unless lookupMaxPages overrides it.
+const (
+	lookupPageSize        = 100
+	defaultLookupMaxPages = 10
+)
+
+// MatchMode selects how lookups compare names.
+type MatchMode string
+
+// Match modes for name lookups.
+const (
+	MatchExact           MatchMode = "exact"  // exact, case-sensitive
+	MatchCaseInsensitive MatchMode = "iexact" // exact, ignoring case
+	MatchPrefix          MatchMode = "prefix" // name starts with the query, ignoring case
+	MatchFuzzy           MatchMode = "fuzzy"  // name contains the query, ignoring case
+)
+
+// LookupOptions controls name matching. The zero value matches fuzzily,
+// tolerates several matches and does not cache.
+type LookupOptions struct {
+	Mode MatchMode
+	// Strict fails with an *AmbiguousError when more than one object matches.
+	Strict bool
+	// MaxPages bounds how many pages of 100 objects a lookup reads; zero
+	// means defaultLookupMaxPages.
+	MaxPages int
+	// CacheConfig sizes the cache a provider attaches in New.
+	CacheConfig LookupCacheConfig
+	// Cache, when set, serves repeated lookups without calling PagerDuty.
+	Cache *LookupCache
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL+"/services?"+params.Encode(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+// ParseLookupOptions reads lookupMatchMode, lookupStrict, lookupMaxPages and
+// the lookupCache* settings from adapter config.
+func ParseLookupOptions(cfg map[string]any) LookupOptions {
+	opts := LookupOptions{CacheConfig: parseLookupCacheConfig(cfg)}
+	if v, ok := cfg["lookupMatchMode"].(string); ok {
+		opts.Mode = MatchMode(strings.ToLower(strings.TrimSpace(v)))
 	}
+	if v, ok := cfg["lookupStrict"].(bool); ok {
+		opts.Strict = v
+	}
+	if v, ok := IntFromConfig(cfg["lookupMaxPages"]); ok && v > 0 {
+		opts.MaxPages = v
+	}
+	return opts
+}
 
-	req.Header.Set("Authorization", "Token token="+apiToken)
-	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+func (o LookupOptions) maxPages() int {
+	if o.MaxPages > 0 {
+		return o.MaxPages
+	}
+	return defaultLookupMaxPages
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
+// Validate rejects unknown match modes.
+func (o LookupOptions) Validate() error {
+	switch o.Mode {
+	case "", MatchExact, MatchCaseInsensitive, MatchPrefix, MatchFuzzy:
+		return nil
+	default:
+		return fmt.Errorf("pagerduty lookupMatchMode %q is not supported, must be one of exact, iexact, prefix, fuzzy", o.Mode)
 	}
-	defer resp.Body.Close()
+}
+
+// AmbiguousError reports a strict lookup that matched more than one object.
+// It matches ErrValidation.
+type AmbiguousError struct {
+	Kind       string   // "service", "team", ...
+	Name       string   // the name that was looked up
+	Candidates []string // "name (ID)" of every match
+}
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("pagerduty api error: %d %s", resp.StatusCode, string(bodyBytes))
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("ambiguous %s scope %q matches %d objects: %s", e.Kind, e.Name, len(e.Candidates), strings.Join(e.Candidates, ", "))
+}
+
+// Is makes AmbiguousError match ErrValidation.
+func (e *AmbiguousError) Is(target error) bool { return target == ErrValidation }
+
+// LookupResult is one object matched by a name lookup.
+type LookupResult struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Quality is the strongest mode the object's name (or email, for users)
+	// satisfies: exact, iexact, prefix or fuzzy.
+	Quality MatchMode `json:"quality"`
+}
+
+// LookupResults are the matches of a lookup, best matches first.
+type LookupResults []LookupResult
+
+// IDs returns the IDs of the results in order.
+func (r LookupResults) IDs() []string {
+	var ids []string
+	for _, res := range r {
+		ids = append(ids, res.ID)
 	}
+	return ids
+}
 
+// LookupServices queries PagerDuty services by name and returns the matches.
+func LookupServices(ctx context.Context, c *Client, name string, opts LookupOptions) (LookupResults, error) {
+	return lookupByName(ctx, c, "/services", "services", "service", name, opts)
+}
+
+// LookupTeams queries PagerDuty teams by name and returns the matches.
+func LookupTeams(ctx context.Context, c *Client, name string, opts LookupOptions) (LookupResults, error) {
+	return lookupByName(ctx, c, "/teams", "teams", "team", name, opts)
+}
+
+// LookupUsers queries PagerDuty users by name or email and returns the matches.
+func LookupUsers(ctx context.Context, c *Client, name string, opts LookupOptions) (LookupResults, error) {
+	return lookupByName(ctx, c, "/users", "users", "user", name, opts)
+}
+
+// LookupEscalationPolicies queries PagerDuty escalation policies by name and
+// returns the matches.
+func LookupEscalationPolicies(ctx context.Context, c *Client, name string, opts LookupOptions) (LookupResults, error) {
+	return lookupByName(ctx, c, "/escalation_policies", "escalation_policies", "escalation policy", name, opts)
+}
+
+// LookupPriorities lists the account's incident priorities and returns those
+// whose name matches.
+func LookupPriorities(ctx context.Context, c *Client, name string, opts LookupOptions) (LookupResults, error) {
+	return lookupByName(ctx, c, "/priorities", "priorities", "priority", name, opts)
+}
+
+// Priority is an incident priority defined on the PagerDuty account.
+type Priority struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ListPriorities returns the account's incident priorities, highest first.
+func ListPriorities(ctx context.Context, c *Client) ([]Priority, error) {
 	var result struct {
-		Services []struct {
-			ID   string `json:"id"`
-			Name string `json:"name"`
-		} `json:"services"`
+		Priorities []Priority `json:"priorities"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	if err := c.Get(ctx, "/priorities", nil, &result); err != nil {
+		return nil, err
 	}
+	return result.Priorities, nil
+}
 
-	// Filter to exact or fuzzy matches
-	var ids []string
-	lowerName := strings.ToLower(name)
-	for _, svc := range result.Services {
-		if strings.Contains(strings.ToLower(svc.Name), lowerName) {
-			ids = append(ids, svc.ID)
+// lookupEntry is one object returned by a list endpoint.
+type lookupEntry struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// lookupByName pages through a list endpoint filtered by query and returns
+// the entries whose name (or email, for users) matches according to opts,
+// ordered by match quality. At most opts.MaxPages pages of 100 are read.
+// In the prefix and fuzzy modes, case-insensitive exact matches win over
+// partial ones when there are any. Matches are cached before the strictness
+// check, so an ambiguous name is reported again from the cache.
+func lookupByName(ctx context.Context, c *Client, path, collection, kind, name string, opts LookupOptions) (LookupResults, error) {
+	key := lookupCacheKey{collection: collection, mode: opts.Mode, name: name}
+	matches, cached := opts.Cache.get(key)
+	if !cached {
+		entries, err := listEntries(ctx, c, path, collection, name, opts.maxPages())
+		if err != nil {
+			return nil, err
 		}
+		matches = matchEntries(entries, name, opts.Mode)
+		opts.Cache.put(key, matches)
 	}
 
-	return ids, nil
+	if opts.Strict && len(matches) > 1 {
+		candidates := make([]string, len(matches))
+		for i, m := range matches {
+			candidates[i] = fmt.Sprintf("%s (%s)", m.Name, m.ID)
+		}
+		return nil, &AmbiguousError{Kind: kind, Name: name, Candidates: candidates}
+	}
+	return matches, nil
 }
 
-// LookupTeamIDsByName queries PagerDuty teams by name and returns matching team IDs.
-func LookupTeamIDsByName(ctx context.Context, client *http.Client, apiURL, apiToken, name string) ([]string, error) {
+// listEntries follows PagerDuty's offset/more pagination for a list endpoint.
+func listEntries(ctx context.Context, c *Client, path, collection, name string, maxPages int) ([]lookupEntry, error) {
 	params := url.Values{}
 	params.Set("query", name)
-	params.Set("limit", "100")
+	params.Set("limit", strconv.Itoa(lookupPageSize))
 
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL+"/teams?"+params.Encode(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Token token="+apiToken)
-	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
-	}
-	defer resp.Body.Close()
+	var out []lookupEntry
+	for page, offset := 0, 0; page < maxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		params.Set("offset", strconv.Itoa(offset))
+		var result map[string]json.RawMessage
+		if err := c.Get(ctx, path, params, &result); err != nil {
+			return nil, err
+		}
+		var entries []lookupEntry
+		if raw, ok := result[collection]; ok {
+			if err := json.Unmarshal(raw, &entries); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, entries...)
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("pagerduty api error: %d %s", resp.StatusCode, string(bodyBytes))
+		var more bool
+		if raw, ok := result["more"]; ok {
+			_ = json.Unmarshal(raw, &more)
+		}
+		offset += len(entries)
+		if !more || len(entries) == 0 {
+			break
+		}
 	}
+	return out, nil
+}
 
-	var result struct {
-		Teams []struct {
-			ID   string `json:"id"`
-			Name string `json:"name"`
-		} `json:"teams"`
+// matchEntries keeps the entries that match name in mode, best first. Ties
+// keep PagerDuty's order. Only the best group of matches is kept: exact
+// matches when there are any, else case-insensitive exact matches, else every
+// partial match.
+func matchEntries(entries []lookupEntry, name string, mode MatchMode) LookupResults {
+	var out LookupResults
+	for _, entry := range entries {
+		quality := matchQuality(entry.Name, name)
+		if entry.Email != "" {
+			if q := matchQuality(entry.Email, name); matchRank(q) > matchRank(quality) {
+				quality = q
+			}
+		}
+		if quality != "" && matchRank(quality) >= matchRank(mode) {
+			out = append(out, LookupResult{ID: entry.ID, Name: entry.Name, Quality: quality})
+		}
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	slices.SortStableFunc(out, func(a, b LookupResult) int {
+		return matchRank(b.Quality) - matchRank(a.Quality)
+	})
+	if len(out) > 0 && matchRank(out[0].Quality) >= matchRank(MatchCaseInsensitive) {
+		best := out[0].Quality
+		n := 1
+		for n < len(out) && out[n].Quality == best {
+			n++
+		}
+		out = out[:n]
 	}
+	return out
+}
 
-	// Filter to exact or fuzzy matches
-	var ids []string
-	lowerName := strings.ToLower(name)
-	for _, team := range result.Teams {
-		if strings.Contains(strings.ToLower(team.Name), lowerName) {
-			ids = append(ids, team.ID)
-		}
+// matchQuality returns the strongest mode in which candidate matches name,
+// or "" if it does not match at all.
+func matchQuality(candidate, name string) MatchMode {
+	lower, lowerName := strings.ToLower(candidate), strings.ToLower(name)
+	switch {
+	case candidate == name:
+		return MatchExact
+	case lower == lowerName:
+		return MatchCaseInsensitive
+	case strings.HasPrefix(lower, lowerName):
+		return MatchPrefix
+	case strings.Contains(lower, lowerName):
+		return MatchFuzzy
 	}
+	return ""
+}
 
-	return ids, nil
+// matchRank orders match modes from loosest (fuzzy, or unset) to strictest
+// (exact).
+func matchRank(mode MatchMode) int {
+	switch mode {
+	case MatchExact:
+		return 3
+	case MatchCaseInsensitive:
+		return 2
+	case MatchPrefix:
+		return 1
+	case MatchFuzzy:
+		return 0
+	}
+	return -1
 }
@@ -3,12 +3,14 @@ package common
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 )
 
-func TestLookupServiceIDsByName(t *testing.T) {
+func TestLookupServices(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/services" {
 			w.WriteHeader(http.StatusNotFound)
@@ -41,13 +43,14 @@ func TestLookupServiceIDsByName(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := &http.Client{}
+	client := NewClient(&http.Client{}, server.URL, "token")
 	ctx := context.Background()
 
 	t.Run("exact match", func(t *testing.T) {
-		ids, err := LookupServiceIDsByName(ctx, client, server.URL, "token", "Production API")
+		matches, err := LookupServices(ctx, client, "Production API", LookupOptions{})
+		ids := matches.IDs()
 		if err != nil {
-			t.Fatalf("LookupServiceIDsByName() error = %v", err)
+			t.Fatalf("LookupServices() error = %v", err)
 		}
 		if len(ids) != 1 {
 			t.Errorf("expected 1 ID, got %d", len(ids))
@@ -58,9 +61,10 @@ func TestLookupServiceIDsByName(t *testing.T) {
 	})
 
 	t.Run("fuzzy match", func(t *testing.T) {
-		ids, err := LookupServiceIDsByName(ctx, client, server.URL, "token", "production")
+		matches, err := LookupServices(ctx, client, "production", LookupOptions{})
+		ids := matches.IDs()
 		if err != nil {
-			t.Fatalf("LookupServiceIDsByName() error = %v", err)
+			t.Fatalf("LookupServices() error = %v", err)
 		}
 		if len(ids) != 2 {
 			t.Errorf("expected 2 IDs (Production API and Production Database), got %d", len(ids))
@@ -68,9 +72,10 @@ func TestLookupServiceIDsByName(t *testing.T) {
 	})
 
 	t.Run("no match", func(t *testing.T) {
-		ids, err := LookupServiceIDsByName(ctx, client, server.URL, "token", "nonexistent")
+		matches, err := LookupServices(ctx, client, "nonexistent", LookupOptions{})
+		ids := matches.IDs()
 		if err != nil {
-			t.Fatalf("LookupServiceIDsByName() error = %v", err)
+			t.Fatalf("LookupServices() error = %v", err)
 		}
 		if len(ids) != 0 {
 			t.Errorf("expected 0 IDs, got %d", len(ids))
@@ -78,7 +83,7 @@ func TestLookupServiceIDsByName(t *testing.T) {
 	})
 }
 
-func TestLookupTeamIDsByName(t *testing.T) {
+func TestLookupTeams(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/teams" {
 			w.WriteHeader(http.StatusNotFound)
@@ -111,13 +116,14 @@ func TestLookupTeamIDsByName(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := &http.Client{}
+	client := NewClient(&http.Client{}, server.URL, "token")
 	ctx := context.Background()
 
 	t.Run("exact match", func(t *testing.T) {
-		ids, err := LookupTeamIDsByName(ctx, client, server.URL, "token", "Platform Team")
+		matches, err := LookupTeams(ctx, client, "Platform Team", LookupOptions{})
+		ids := matches.IDs()
 		if err != nil {
-			t.Fatalf("LookupTeamIDsByName() error = %v", err)
+			t.Fatalf("LookupTeams() error = %v", err)
 		}
 		if len(ids) != 1 {
 			t.Errorf("expected 1 ID, got %d", len(ids))
@@ -128,9 +134,10 @@ func TestLookupTeamIDsByName(t *testing.T) {
 	})
 
 	t.Run("fuzzy match", func(t *testing.T) {
-		ids, err := LookupTeamIDsByName(ctx, client, server.URL, "token", "platform")
+		matches, err := LookupTeams(ctx, client, "platform", LookupOptions{})
+		ids := matches.IDs()
 		if err != nil {
-			t.Fatalf("LookupTeamIDsByName() error = %v", err)
+			t.Fatalf("LookupTeams() error = %v", err)
 		}
 		if len(ids) != 2 {
 			t.Errorf("expected 2 IDs (Platform Team and Platform Infrastructure), got %d", len(ids))
@@ -138,9 +145,10 @@ func TestLookupTeamIDsByName(t *testing.T) {
 	})
 
 	t.Run("no match", func(t *testing.T) {
-		ids, err := LookupTeamIDsByName(ctx, client, server.URL, "token", "nonexistent")
+		matches, err := LookupTeams(ctx, client, "nonexistent", LookupOptions{})
+		ids := matches.IDs()
 		if err != nil {
-			t.Fatalf("LookupTeamIDsByName() error = %v", err)
+			t.Fatalf("LookupTeams() error = %v", err)
 		}
 		if len(ids) != 0 {
 			t.Errorf("expected 0 IDs, got %d", len(ids))
@@ -148,33 +156,232 @@ func TestLookupTeamIDsByName(t *testing.T) {
 	})
 }
 
-func TestLookupServiceIDsByName_APIError(t *testing.T) {
+func TestLookupMatchModes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"services": []map[string]any{
+				{"id": "PSVC1", "name": "api"},
+				{"id": "PSVC2", "name": "api-gateway"},
+				{"id": "PSVC3", "name": "rapid-deploy"},
+				{"id": "PSVC4", "name": "API-Legacy"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, server.URL, "token")
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		query string
+		opts  LookupOptions
+		want  []string
+	}{
+		{"fuzzy prefers exact", "api", LookupOptions{}, []string{"PSVC1"}},
+		{"fuzzy without exact", "ap", LookupOptions{Mode: MatchFuzzy}, []string{"PSVC1", "PSVC2", "PSVC4", "PSVC3"}},
+		{"prefix", "api-", LookupOptions{Mode: MatchPrefix}, []string{"PSVC2", "PSVC4"}},
+		{"exact is case-sensitive", "API", LookupOptions{Mode: MatchExact}, nil},
+		{"iexact", "API", LookupOptions{Mode: MatchCaseInsensitive}, []string{"PSVC1"}},
+		{"strict with single match", "api", LookupOptions{Strict: true}, []string{"PSVC1"}},
+	}
+	for _, tt := range tests {
+		matches, err := LookupServices(ctx, client, tt.query, tt.opts)
+		ids := matches.IDs()
+		if err != nil {
+			t.Fatalf("%s: error = %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+
+	_, err := LookupServices(ctx, client, "api-", LookupOptions{Mode: MatchPrefix, Strict: true})
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) || !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected AmbiguousError matching ErrValidation, got %v", err)
+	}
+	if want := []string{"api-gateway (PSVC2)", "API-Legacy (PSVC4)"}; !reflect.DeepEqual(ambiguous.Candidates, want) {
+		t.Errorf("Candidates = %v, want %v", ambiguous.Candidates, want)
+	}
+}
+
+func TestLookupExactBeatsCaseInsensitive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"services": []map[string]any{
+				{"id": "PSVC1", "name": "API"},
+				{"id": "PSVC2", "name": "api"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, server.URL, "token")
+	ctx := context.Background()
+
+	for _, mode := range []MatchMode{MatchCaseInsensitive, MatchFuzzy} {
+		matches, err := LookupServices(ctx, client, "api", LookupOptions{Mode: mode, Strict: true})
+		if err != nil {
+			t.Fatalf("%s: error = %v", mode, err)
+		}
+		if ids := matches.IDs(); !reflect.DeepEqual(ids, []string{"PSVC2"}) {
+			t.Errorf("%s: got %v, want [PSVC2]", mode, ids)
+		}
+	}
+
+	// Without an exact match both case-insensitive matches remain.
+	_, err := LookupServices(ctx, client, "Api", LookupOptions{Mode: MatchCaseInsensitive, Strict: true})
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected AmbiguousError, got %v", err)
+	}
+}
+
+func TestLookupPaginates(t *testing.T) {
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+		page := map[string]any{
+			"0": []map[string]any{{"id": "PSVC1", "name": "checkout-worker"}, {"id": "PSVC2", "name": "checkout-api"}},
+			"2": []map[string]any{{"id": "PSVC3", "name": "Checkout"}, {"id": "PSVC4", "name": "legacy-checkout"}},
+			"4": []map[string]any{{"id": "PSVC5", "name": "checkout"}},
+		}[offset]
+		json.NewEncoder(w).Encode(map[string]any{"services": page, "more": offset != "4"})
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, server.URL, "token")
+	ctx := context.Background()
+
+	matches, err := LookupServices(ctx, client, "checkout", LookupOptions{Mode: MatchFuzzy})
+	if err != nil {
+		t.Fatalf("LookupServices() error = %v", err)
+	}
+	want := LookupResults{{ID: "PSVC5", Name: "checkout", Quality: MatchExact}}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("LookupServices() = %+v, want %+v", matches, want)
+	}
+	if !reflect.DeepEqual(offsets, []string{"0", "2", "4"}) {
+		t.Errorf("offsets = %v, want [0 2 4]", offsets)
+	}
+
+	// The page cap stops before the exact match on the last page.
+	offsets = nil
+	matches, err = LookupServices(ctx, client, "checkout", LookupOptions{Mode: MatchFuzzy, MaxPages: 1})
+	if err != nil {
+		t.Fatalf("LookupServices() error = %v", err)
+	}
+	want = LookupResults{
+		{ID: "PSVC1", Name: "checkout-worker", Quality: MatchPrefix},
+		{ID: "PSVC2", Name: "checkout-api", Quality: MatchPrefix},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("capped LookupServices() = %+v, want %+v", matches, want)
+	}
+	if len(offsets) != 1 {
+		t.Errorf("expected 1 request with MaxPages 1, got %d", len(offsets))
+	}
+}
+
+func TestParseLookupOptions(t *testing.T) {
+	opts := ParseLookupOptions(map[string]any{"lookupMatchMode": " Prefix ", "lookupStrict": true, "lookupMaxPages": float64(3)})
+	if opts.Mode != MatchPrefix || !opts.Strict || opts.MaxPages != 3 {
+		t.Errorf("ParseLookupOptions() = %+v", opts)
+	}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (LookupOptions{Mode: "regex"}).Validate(); err == nil {
+		t.Error("expected error for unknown match mode")
+	}
+}
+
+func TestLookupUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"users": []map[string]any{
+				{"id": "PUSER1", "name": "Jane Doe", "email": "jane@example.com"},
+				{"id": "PUSER2", "name": "John Roe", "email": "john@example.com"},
+			},
+			"limit": 100,
+			"more":  false,
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{}, server.URL, "token")
+	ctx := context.Background()
+
+	for _, name := range []string{"jane doe", "jane@example.com"} {
+		matches, err := LookupUsers(ctx, client, name, LookupOptions{})
+		ids := matches.IDs()
+		if err != nil {
+			t.Fatalf("LookupUsers(%q) error = %v", name, err)
+		}
+		if len(ids) != 1 || ids[0] != "PUSER1" {
+			t.Errorf("LookupUsers(%q) = %v, want [PUSER1]", name, ids)
+		}
+	}
+}
+
+func TestLookupPriorities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/priorities" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"priorities": []map[string]any{
+				{"id": "PPRIO1", "name": "P1"},
+				{"id": "PPRIO2", "name": "P2"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	matches, err := LookupPriorities(context.Background(), NewClient(&http.Client{}, server.URL, "token"), "p2", LookupOptions{})
+	ids := matches.IDs()
+	if err != nil {
+		t.Fatalf("LookupPriorities() error = %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "PPRIO2" {
+		t.Errorf("LookupPriorities() = %v, want [PPRIO2]", ids)
+	}
+}
+
+func TestLookupServices_APIError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(`{"error": "internal error"}`))
 	}))
 	defer server.Close()
 
-	client := &http.Client{}
+	client := NewClient(&http.Client{}, server.URL, "token")
 	ctx := context.Background()
 
-	_, err := LookupServiceIDsByName(ctx, client, server.URL, "token", "test")
+	_, err := LookupServices(ctx, client, "test", LookupOptions{})
 	if err == nil {
 		t.Error("expected error for API failure")
 	}
 }
 
-func TestLookupTeamIDsByName_APIError(t *testing.T) {
+func TestLookupTeams_APIError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusForbidden)
 		w.Write([]byte(`{"error": "access denied"}`))
 	}))
 	defer server.Close()
 
-	client := &http.Client{}
+	client := NewClient(&http.Client{}, server.URL, "token")
 	ctx := context.Background()
 
-	_, err := LookupTeamIDsByName(ctx, client, server.URL, "token", "test")
+	_, err := LookupTeams(ctx, client, "test", LookupOptions{})
 	if err == nil {
 		t.Error("expected error for API failure")
 	}
@@ -0,0 +1,138 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that paces requests to the PagerDuty REST API
+// so a plugin stays under the per-token rate limit instead of running into 429s.
+type RateLimiter struct {
+	mu        sync.Mutex
+	perMinute int
+	capacity  float64
+	tokens    float64
+	perSecond float64
+	last      time.Time
+	now       func() time.Time
+}
+
+// RateLimitBudget describes the limiter state exposed to OpsOrch Core.
+type RateLimitBudget struct {
+	LimitPerMinute int `json:"limitPerMinute"`
+	Remaining      int `json:"remaining"`
+}
+
+// NewRateLimiter returns a limiter that allows perMinute requests per minute
+// with bursts up to the full per-minute budget.
+func NewRateLimiter(perMinute int) *RateLimiter {
+	return &RateLimiter{
+		perMinute: perMinute,
+		capacity:  float64(perMinute),
+		tokens:    float64(perMinute),
+		perSecond: float64(perMinute) / 60,
+		last:      time.Now(),
+		now:       time.Now,
+	}
+}
+
+var (
+	sharedLimitersMu sync.Mutex
+	sharedLimiters   = map[string]*RateLimiter{}
+)
+
+// SharedRateLimiter returns the process-wide limiter for an API token, creating
+// it on first use. Providers in the same plugin process that use the same token
+// therefore draw from one budget. The rate of the first caller wins.
+func SharedRateLimiter(apiToken string, perMinute int) *RateLimiter {
+	sharedLimitersMu.Lock()
+	defer sharedLimitersMu.Unlock()
+	if l, ok := sharedLimiters[apiToken]; ok {
+		return l
+	}
+	l := NewRateLimiter(perMinute)
+	sharedLimiters[apiToken] = l
+	return l
+}
+
+// Wait blocks until a request may be sent. If the context deadline would pass
+// before a token becomes available, Wait fails immediately instead of queueing.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	l.refill()
+	l.tokens--
+	if l.tokens >= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	wait := time.Duration(-l.tokens / l.perSecond * float64(time.Second))
+	l.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok && l.now().Add(wait).After(deadline) {
+		l.release()
+		return fmt.Errorf("%w: waiting %s for client-side budget would exceed context deadline", ErrRateLimited, wait.Round(time.Millisecond))
+	}
+	if err := sleepContext(ctx, wait); err != nil {
+		l.release()
+		return err
+	}
+	return nil
+}
+
+// Budget returns the configured limit and the number of requests that can be
+// sent right now without waiting.
+func (l *RateLimiter) Budget() RateLimitBudget {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	remaining := int(l.tokens)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return RateLimitBudget{LimitPerMinute: l.perMinute, Remaining: remaining}
+}
+
+// refill adds tokens for the time elapsed since the last call. Callers must
+// hold l.mu.
+func (l *RateLimiter) refill() {
+	now := l.now()
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	l.tokens += elapsed * l.perSecond
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+}
+
+// release returns a reserved token that was not used.
+func (l *RateLimiter) release() {
+	l.mu.Lock()
+	l.tokens++
+	l.mu.Unlock()
+}
+
+// rateLimitedTransport waits on a RateLimiter before every round trip.
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *RateLimiter
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// NewHTTPClient returns the http.Client used by the providers. When limiter is
+// non-nil every request, including retries, is paced through it.
+func NewHTTPClient(limiter *RateLimiter) *http.Client {
+	client := &http.Client{Timeout: 30 * time.Second}
+	if limiter != nil {
+		client.Transport = &rateLimitedTransport{base: http.DefaultTransport, limiter: limiter}
+	}
+	return client
+}
@@ -0,0 +1,90 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBudget(t *testing.T) {
+	l := NewRateLimiter(60)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	l.last = now
+
+	for i := 0; i < 10; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	if got := l.Budget(); got.LimitPerMinute != 60 || got.Remaining != 50 {
+		t.Errorf("Budget() = %+v, want limit 60 remaining 50", got)
+	}
+
+	// One token per second is refilled.
+	now = now.Add(5 * time.Second)
+	if got := l.Budget().Remaining; got != 55 {
+		t.Errorf("Remaining after refill = %d, want 55", got)
+	}
+}
+
+func TestRateLimiterRespectsDeadline(t *testing.T) {
+	l := NewRateLimiter(1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.Wait(ctx); err == nil {
+		t.Fatalf("expected error when the wait exceeds the deadline")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Wait() should fail fast instead of sleeping")
+	}
+	if got := l.Budget().Remaining; got != 0 {
+		t.Errorf("Remaining = %d, want 0", got)
+	}
+}
+
+func TestSharedRateLimiter(t *testing.T) {
+	a := SharedRateLimiter("token-shared-test", 100)
+	b := SharedRateLimiter("token-shared-test", 200)
+	if a != b {
+		t.Fatalf("expected the same limiter for the same token")
+	}
+	if SharedRateLimiter("token-other-test", 100) == a {
+		t.Fatalf("expected a separate limiter for another token")
+	}
+}
+
+func TestNewHTTPClientPacesRequests(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(2)
+	c := NewClient(NewHTTPClient(limiter), server.URL, "token")
+
+	for i := 0; i < 2; i++ {
+		if err := c.Get(context.Background(), "/services", nil, nil); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Get(ctx, "/services", nil, nil); err == nil {
+		t.Fatalf("expected the third request to be rejected by the limiter")
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+}
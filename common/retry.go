@@ -0,0 +1,144 @@
+package common
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how idempotent requests are retried when PagerDuty
+// responds with 429 or a 5xx status. The zero value disables retries.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first one
+	BaseDelay   time.Duration // delay before the first retry, doubled on each attempt
+	MaxDelay    time.Duration // upper bound for any single delay
+}
+
+// DefaultRetryPolicy returns the policy used when the adapter config does not
+// override it.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// ParseRetryPolicy reads retryMaxAttempts, retryBaseDelay and retryMaxDelay
+// from decrypted adapter config, falling back to DefaultRetryPolicy. Delays
+// accept Go duration strings ("250ms") or a number of milliseconds.
+func ParseRetryPolicy(cfg map[string]any) RetryPolicy {
+	out := DefaultRetryPolicy()
+	if v, ok := IntFromConfig(cfg["retryMaxAttempts"]); ok && v >= 0 {
+		out.MaxAttempts = v
+	}
+	if v, ok := DurationFromConfig(cfg["retryBaseDelay"]); ok && v >= 0 {
+		out.BaseDelay = v
+	}
+	if v, ok := DurationFromConfig(cfg["retryMaxDelay"]); ok && v >= 0 {
+		out.MaxDelay = v
+	}
+	return out
+}
+
+// retryable reports whether another attempt should be made after err.
+func (r RetryPolicy) retryable(err error, attempt int) bool {
+	if attempt >= r.MaxAttempts {
+		return false
+	}
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+}
+
+// delay returns how long to wait before the next attempt. A server-provided
+// hint takes precedence; otherwise exponential backoff with jitter is used.
+func (r RetryPolicy) delay(attempt int, hint time.Duration) time.Duration {
+	if hint > 0 {
+		if r.MaxDelay > 0 && hint > r.MaxDelay {
+			return r.MaxDelay
+		}
+		return hint
+	}
+	if r.BaseDelay <= 0 {
+		return 0
+	}
+	d := r.BaseDelay << (attempt - 1)
+	if d <= 0 || (r.MaxDelay > 0 && d > r.MaxDelay) {
+		d = r.MaxDelay
+	}
+	// Jitter between half and the full backoff so concurrent callers spread out.
+	half := int64(d / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// retryAfter extracts a wait hint from Retry-After (seconds or HTTP date) or
+// PagerDuty's ratelimit-reset header (seconds until the window resets).
+func retryAfter(h http.Header) time.Duration {
+	if v := strings.TrimSpace(h.Get("Retry-After")); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return time.Until(at)
+		}
+	}
+	if v := strings.TrimSpace(h.Get("ratelimit-reset")); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(secs * float64(time.Second))
+		}
+	}
+	return 0
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// IntFromConfig converts a JSON-decoded config value into an int.
+func IntFromConfig(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// DurationFromConfig converts a JSON-decoded config value into a duration.
+// Strings are parsed with time.ParseDuration; numbers are milliseconds.
+func DurationFromConfig(v any) (time.Duration, bool) {
+	switch d := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(strings.TrimSpace(d))
+		return parsed, err == nil
+	case int, int64, float64:
+		ms, _ := IntFromConfig(d)
+		return time.Duration(ms) * time.Millisecond, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientRetriesIdempotentRequests(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if attempts == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(&http.Client{}, server.URL, "token")
+	c.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	if err := c.Get(context.Background(), "/incidents", nil, nil); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestClientRetryLimits(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := NewClient(&http.Client{}, server.URL, "token")
+	c.Retry = RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		attempts = 0
+		err := c.Get(context.Background(), "/incidents", nil, nil)
+		if !IsStatus(err, http.StatusBadGateway) {
+			t.Fatalf("expected 502 APIError, got %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf("attempts = %d, want 2", attempts)
+		}
+	})
+
+	t.Run("does not retry writes", func(t *testing.T) {
+		attempts = 0
+		if err := c.Post(context.Background(), "/incidents", map[string]any{}, nil); err == nil {
+			t.Fatalf("expected error")
+		}
+		if attempts != 1 {
+			t.Errorf("attempts = %d, want 1", attempts)
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"retry-after seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{"ratelimit-reset", http.Header{"Ratelimit-Reset": {"2"}}, 2 * time.Second},
+		{"none", http.Header{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.header); got != tt.want {
+				t.Errorf("retryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	r := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	if got := r.delay(1, 5*time.Second); got != time.Second {
+		t.Errorf("hint should be capped at MaxDelay, got %v", got)
+	}
+	if got := r.delay(3, 0); got < 200*time.Millisecond || got > 400*time.Millisecond {
+		t.Errorf("delay(3) = %v, want within [200ms, 400ms]", got)
+	}
+	if got := r.delay(10, 0); got > time.Second {
+		t.Errorf("delay(10) = %v, want <= MaxDelay", got)
+	}
+}
+
+func TestParseRetryPolicy(t *testing.T) {
+	if got := ParseRetryPolicy(map[string]any{}); got != DefaultRetryPolicy() {
+		t.Errorf("expected default policy, got %+v", got)
+	}
+	got := ParseRetryPolicy(map[string]any{
+		"retryMaxAttempts": float64(6),
+		"retryBaseDelay":   "250ms",
+		"retryMaxDelay":    float64(2000),
+	})
+	want := RetryPolicy{MaxAttempts: 6, BaseDelay: 250 * time.Millisecond, MaxDelay: 2 * time.Second}
+	if got != want {
+		t.Errorf("ParseRetryPolicy() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Sort is a requested result order, read from query metadata.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort reads Metadata["sort_by"] and Metadata["sort_order"]. sort_by is a
+// field name, optionally suffixed with ":asc" or ":desc" as in PagerDuty's
+// sort_by parameter; sort_order ("asc" or "desc") applies when there is no
+// suffix. Fields outside allowed are rejected as validation errors. The zero
+// Sort means no sort was requested.
+func ParseSort(md map[string]any, allowed []string) (Sort, error) {
+	raw, _ := md["sort_by"].(string)
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "" {
+		return Sort{}, nil
+	}
+	field, order, hasOrder := strings.Cut(raw, ":")
+	if !hasOrder {
+		order, _ = md["sort_order"].(string)
+		order = strings.ToLower(strings.TrimSpace(order))
+	}
+	if !slices.Contains(allowed, field) {
+		return Sort{}, Invalid(fmt.Errorf("sort_by %q is not supported, must be one of %s", field, strings.Join(allowed, ", ")))
+	}
+	switch order {
+	case "", "asc":
+		return Sort{Field: field}, nil
+	case "desc":
+		return Sort{Field: field, Desc: true}, nil
+	default:
+		return Sort{}, Invalid(fmt.Errorf("sort order %q must be asc or desc", order))
+	}
+}
+
+// IsZero reports whether no sort was requested.
+func (s Sort) IsZero() bool { return s.Field == "" }
+
+// Param formats the sort as a PagerDuty sort_by value.
+func (s Sort) Param() string {
+	if s.Desc {
+		return s.Field + ":desc"
+	}
+	return s.Field + ":asc"
+}
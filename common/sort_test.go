@@ -0,0 +1,38 @@
+package common
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"created_at", "name"}
+	tests := []struct {
+		md      map[string]any
+		want    Sort
+		param   string
+		wantErr bool
+	}{
+		{md: nil, want: Sort{}},
+		{md: map[string]any{"sort_by": "created_at"}, want: Sort{Field: "created_at"}, param: "created_at:asc"},
+		{md: map[string]any{"sort_by": "Created_At:DESC"}, want: Sort{Field: "created_at", Desc: true}, param: "created_at:desc"},
+		{md: map[string]any{"sort_by": "name", "sort_order": "desc"}, want: Sort{Field: "name", Desc: true}, param: "name:desc"},
+		{md: map[string]any{"sort_by": "urgency"}, wantErr: true},
+		{md: map[string]any{"sort_by": "name:sideways"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSort(tt.md, allowed)
+		if tt.wantErr {
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("ParseSort(%v) error = %v, want ErrValidation", tt.md, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSort(%v) = %+v, %v, want %+v", tt.md, got, err, tt.want)
+		}
+		if !got.IsZero() && got.Param() != tt.param {
+			t.Errorf("Param() = %q, want %q", got.Param(), tt.param)
+		}
+	}
+}
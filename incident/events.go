@@ -0,0 +1,136 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+// eventLookupDelays are the waits before each repeated incident lookup after
+// an event is enqueued, about two seconds in total.
+var eventLookupDelays = []time.Duration{250 * time.Millisecond, 500 * time.Millisecond, time.Second}
+
+// createViaEvents triggers an event on the Events API v2. PagerDuty opens (or
+// deduplicates into) an incident asynchronously, so the incident is looked up
+// by its dedup key afterwards, retrying briefly. If it still does not exist, a
+// pending incident without an ID is returned; the dedup key is only in its
+// metadata.
+func (p *PagerDutyProvider) createViaEvents(ctx context.Context, in schema.CreateIncidentInput) (schema.Incident, error) {
+	severity := defaultString(in.Severity, p.cfg.DefaultSeverity)
+
+	eventPayload := map[string]any{
+		"summary":  in.Title,
+		"source":   defaultString(metadataString(in.Metadata, "source"), defaultString(in.Service, p.cfg.Source)),
+		"severity": mapSeverityToEventSeverity(severity),
+	}
+	for _, key := range []string{"component", "group", "class"} {
+		if v := metadataString(in.Metadata, key); v != "" {
+			eventPayload[key] = v
+		}
+	}
+
+	details := map[string]any{}
+	for k, v := range in.Fields {
+		details[k] = v
+	}
+	if custom, ok := in.Metadata["custom_details"].(map[string]any); ok {
+		for k, v := range custom {
+			details[k] = v
+		}
+	}
+	if in.Description != "" {
+		details["description"] = in.Description
+	}
+	if len(details) > 0 {
+		eventPayload["custom_details"] = details
+	}
+
+	event := map[string]any{
+		"routing_key":  p.cfg.RoutingKey,
+		"event_action": "trigger",
+		"dedup_key":    p.incidentKey(in),
+		"payload":      eventPayload,
+	}
+
+	var result struct {
+		Status   string `json:"status"`
+		Message  string `json:"message"`
+		DedupKey string `json:"dedup_key"`
+	}
+	if err := p.events().Post(ctx, "/v2/enqueue", event, &result); err != nil {
+		return schema.Incident{}, fmt.Errorf("enqueue event: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("incident_key", result.DedupKey)
+	params.Set("limit", "1")
+	for attempt := 0; ; attempt++ {
+		page, _, err := p.queryPage(ctx, params)
+		if err != nil {
+			return schema.Incident{}, fmt.Errorf("lookup incident for dedup key %q: %w", result.DedupKey, err)
+		}
+		if len(page) > 0 {
+			inc := convertPDIncident(page[0], p.cfg)
+			inc.Metadata["dedup_key"] = result.DedupKey
+			return inc, nil
+		}
+		if attempt == len(eventLookupDelays) {
+			break
+		}
+		timer := time.NewTimer(eventLookupDelays[attempt])
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return schema.Incident{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return schema.Incident{
+		Title:       in.Title,
+		Description: in.Description,
+		Status:      "open",
+		Severity:    severity,
+		Service:     in.Service,
+		Metadata: map[string]any{
+			"source":       p.cfg.Source,
+			"dedup_key":    result.DedupKey,
+			"incident_key": result.DedupKey,
+			"pending":      true,
+		},
+	}, nil
+}
+
+// events returns a client for the Events API v2. Events are authenticated by
+// the routing key, so the REST token is never sent to the events host.
+func (p *PagerDutyProvider) events() *common.Client {
+	return common.NewClient(p.client, p.cfg.EventsURL, "")
+}
+
+// mapSeverityToEventSeverity maps OpsOrch severity to an Events API v2 severity.
+func mapSeverityToEventSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "sev1", "p1":
+		return "critical"
+	case "high", "sev2", "p2", "error":
+		return "error"
+	case "medium", "sev3", "p3", "warning":
+		return "warning"
+	case "low", "sev4", "p4", "info":
+		return "info"
+	default:
+		return "critical"
+	}
+}
+
+func metadataString(m map[string]any, key string) string {
+	if v, ok := m[key].(string); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestNewEventsMode(t *testing.T) {
+	base := map[string]any{"apiToken": "token", "fromEmail": "user@example.com", "createMode": "events"}
+	if _, err := New(base); err == nil {
+		t.Fatalf("expected error when routingKey missing in events mode")
+	}
+	base["routingKey"] = "R0UTING"
+	if _, err := New(base); err != nil {
+		t.Fatalf("expected serviceID to be optional in events mode, got %v", err)
+	}
+	if _, err := New(map[string]any{"apiToken": "token", "fromEmail": "user@example.com", "serviceID": "P1", "createMode": "bogus"}); err == nil {
+		t.Fatalf("expected error for unknown createMode")
+	}
+}
+
+func TestCreateViaEvents(t *testing.T) {
+	var event map[string]any
+	incidentExists := true
+	lookups := 0
+	var onLookup func()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/enqueue" && r.Method == "POST":
+			if r.Header.Get("Authorization") != "" {
+				t.Errorf("REST token must not be sent to the Events API")
+			}
+			json.NewDecoder(r.Body).Decode(&event)
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(map[string]any{
+				"status":    "success",
+				"message":   "Event processed",
+				"dedup_key": "dedup-123",
+			})
+		case r.URL.Path == "/incidents" && r.Method == "GET":
+			if r.URL.Query().Get("incident_key") != "dedup-123" {
+				t.Errorf("incident_key = %q, want dedup-123", r.URL.Query().Get("incident_key"))
+			}
+			lookups++
+			if onLookup != nil {
+				onLookup()
+			}
+			var incidents []map[string]any
+			if incidentExists {
+				incidents = append(incidents, map[string]any{
+					"id":           "PINC1",
+					"incident_key": "dedup-123",
+					"title":        "Disk full",
+					"status":       "triggered",
+					"urgency":      "high",
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"incidents": incidents})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg: Config{
+			Source:          "pagerduty",
+			DefaultSeverity: "critical",
+			APIToken:        "token",
+			APIURL:          server.URL,
+			CreateMode:      CreateModeEvents,
+			RoutingKey:      "R0UTING",
+			EventsURL:       server.URL,
+		},
+		client: &http.Client{},
+	}
+	in := schema.CreateIncidentInput{
+		Title:       "Disk full",
+		Description: "/var is at 99%",
+		Severity:    "high",
+		Service:     "db-01",
+		Fields:      map[string]any{"mount": "/var"},
+		Metadata: map[string]any{
+			"dedup_key": "dedup-123",
+			"component": "postgres",
+			"group":     "storage",
+			"class":     "disk",
+		},
+	}
+
+	inc, err := p.Create(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if inc.ID != "PINC1" {
+		t.Errorf("ID = %s, want PINC1", inc.ID)
+	}
+
+	if event["routing_key"] != "R0UTING" || event["event_action"] != "trigger" || event["dedup_key"] != "dedup-123" {
+		t.Errorf("unexpected event envelope %v", event)
+	}
+	payload := event["payload"].(map[string]any)
+	if payload["summary"] != "Disk full" || payload["severity"] != "error" || payload["source"] != "db-01" {
+		t.Errorf("unexpected payload %v", payload)
+	}
+	if payload["component"] != "postgres" || payload["group"] != "storage" || payload["class"] != "disk" {
+		t.Errorf("unexpected component/group/class in %v", payload)
+	}
+	details := payload["custom_details"].(map[string]any)
+	if details["mount"] != "/var" || details["description"] != "/var is at 99%" {
+		t.Errorf("unexpected custom_details %v", details)
+	}
+
+	delays := eventLookupDelays
+	eventLookupDelays = []time.Duration{0, 0}
+	t.Cleanup(func() { eventLookupDelays = delays })
+
+	t.Run("retries until the incident exists", func(t *testing.T) {
+		incidentExists = false
+		lookups = 0
+		onLookup = func() { incidentExists = lookups == 2 }
+		inc, err := p.Create(context.Background(), in)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if inc.ID != "PINC1" || lookups != 2 {
+			t.Errorf("got ID %q after %d lookups, want PINC1 after 2", inc.ID, lookups)
+		}
+	})
+
+	t.Run("pending when incident not created yet", func(t *testing.T) {
+		incidentExists = false
+		lookups = 0
+		onLookup = nil
+		inc, err := p.Create(context.Background(), in)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if lookups != 3 {
+			t.Errorf("expected 3 lookups, got %d", lookups)
+		}
+		if inc.ID != "" || inc.Metadata["pending"] != true || inc.Metadata["dedup_key"] != "dedup-123" {
+			t.Errorf("expected pending incident without ID, got %+v", inc)
+		}
+	})
+}
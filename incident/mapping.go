@@ -0,0 +1,302 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+// Mappings holds the configured translation tables between OpsOrch and
+// PagerDuty values. Keys are lower-cased. A value missing from a table falls
+// back to the built-in mapping, so the zero value behaves like the defaults.
+type Mappings struct {
+	SeverityToUrgency  map[string]string // OpsOrch severity -> high/low
+	UrgencyToSeverity  map[string]string // high/low -> OpsOrch severity
+	SeverityToPriority map[string]string // OpsOrch severity -> priority name or ID
+	StatusToPD         map[string]string // OpsOrch status -> PagerDuty status
+	PDStatusToStatus   map[string]string // PagerDuty status -> OpsOrch status
+}
+
+var (
+	pdUrgencies = []string{"high", "low"}
+	pdStatuses  = []string{"triggered", "acknowledged", "resolved"}
+)
+
+// parseMappings reads the mapping tables from adapter config.
+func parseMappings(cfg map[string]any) Mappings {
+	return Mappings{
+		SeverityToUrgency:  stringMap(cfg["severityToUrgency"]),
+		UrgencyToSeverity:  stringMap(cfg["urgencyToSeverity"]),
+		SeverityToPriority: stringMap(cfg["severityToPriority"]),
+		StatusToPD:         stringMap(cfg["statusToPD"]),
+		PDStatusToStatus:   stringMap(cfg["pdStatusToStatus"]),
+	}
+}
+
+// validate rejects tables that would send PagerDuty values it does not accept.
+func (m Mappings) validate() error {
+	if err := checkValues("severityToUrgency", m.SeverityToUrgency, pdUrgencies); err != nil {
+		return err
+	}
+	if err := checkKeys("urgencyToSeverity", m.UrgencyToSeverity, pdUrgencies); err != nil {
+		return err
+	}
+	if err := checkValues("statusToPD", m.StatusToPD, pdStatuses); err != nil {
+		return err
+	}
+	if err := checkKeys("pdStatusToStatus", m.PDStatusToStatus, pdStatuses); err != nil {
+		return err
+	}
+	for _, table := range []struct {
+		name   string
+		values map[string]string
+	}{
+		{"urgencyToSeverity", m.UrgencyToSeverity},
+		{"severityToPriority", m.SeverityToPriority},
+		{"pdStatusToStatus", m.PDStatusToStatus},
+	} {
+		for k, v := range table.values {
+			if v == "" {
+				return fmt.Errorf("pagerduty %s[%q] must not be empty", table.name, k)
+			}
+		}
+	}
+	return nil
+}
+
+// urgency maps an OpsOrch severity to a PagerDuty urgency.
+func (m Mappings) urgency(severity string) string {
+	if v, ok := m.SeverityToUrgency[strings.ToLower(severity)]; ok {
+		return v
+	}
+	return mapSeverityToUrgency(severity)
+}
+
+// severity maps a PagerDuty urgency to an OpsOrch severity.
+func (m Mappings) severity(urgency string) string {
+	if v, ok := m.UrgencyToSeverity[strings.ToLower(urgency)]; ok {
+		return v
+	}
+	return mapUrgencyToSeverity(urgency)
+}
+
+// priority returns the PagerDuty priority configured for an OpsOrch severity.
+func (m Mappings) priority(severity string) (string, bool) {
+	v, ok := m.SeverityToPriority[strings.ToLower(severity)]
+	return v, ok
+}
+
+// severityForPriority maps a PagerDuty priority to an OpsOrch severity. A
+// priority configured in severityToPriority (by ID or name) maps back to its
+// severity; when several severities share a priority the first in sort order
+// wins. Otherwise the built-in P1–P5 table applies. It returns "" for unknown
+// priorities.
+func (m Mappings) severityForPriority(id, name string) string {
+	severities := make([]string, 0, len(m.SeverityToPriority))
+	for sev := range m.SeverityToPriority {
+		severities = append(severities, sev)
+	}
+	sort.Strings(severities)
+	for _, sev := range severities {
+		ref := m.SeverityToPriority[sev]
+		if (id != "" && ref == id) || (name != "" && strings.EqualFold(ref, name)) {
+			return sev
+		}
+	}
+	return mapPriorityToSeverity(name)
+}
+
+// pdStatus maps an OpsOrch status to a PagerDuty status.
+func (m Mappings) pdStatus(status string) string {
+	if v, ok := m.StatusToPD[strings.ToLower(status)]; ok {
+		return v
+	}
+	return mapStatusToPD(status)
+}
+
+// status maps a PagerDuty status to an OpsOrch status.
+func (m Mappings) status(pdStatus string) string {
+	if v, ok := m.PDStatusToStatus[strings.ToLower(pdStatus)]; ok {
+		return v
+	}
+	return mapPDStatusToOpsOrch(pdStatus)
+}
+
+// priorityID resolves the priority configured for severity to a PagerDuty
+// priority ID. The configured value may be a priority name (e.g. "P1") or ID.
+// It returns "" when no priority is configured for the severity.
+func (p *PagerDutyProvider) priorityID(ctx context.Context, severity string) (string, error) {
+	ref, ok := p.cfg.Mappings.priority(severity)
+	if !ok {
+		return "", nil
+	}
+	priorities, err := common.ListPriorities(ctx, p.api())
+	if err != nil {
+		return "", fmt.Errorf("list priorities: %w", err)
+	}
+	return resolvePriority(priorities, severity, ref)
+}
+
+// resolvePriority finds the priority matching ref by ID or name.
+func resolvePriority(priorities []common.Priority, severity, ref string) (string, error) {
+	for _, prio := range priorities {
+		if prio.ID == ref || strings.EqualFold(prio.Name, ref) {
+			return prio.ID, nil
+		}
+	}
+	return "", common.Invalid(fmt.Errorf("pagerduty priority %q configured for severity %q does not exist", ref, severity))
+}
+
+// incidentSeverity returns the OpsOrch severity of a PagerDuty incident.
+// Priority is finer grained than urgency, so it decides the severity when the
+// incident has one that maps to a known severity.
+func (m Mappings) incidentSeverity(pdInc pdIncident) string {
+	if pdInc.Priority != nil {
+		name := defaultString(pdInc.Priority.Name, pdInc.Priority.Summary)
+		if sev := m.severityForPriority(pdInc.Priority.ID, name); sev != "" {
+			return sev
+		}
+	}
+	return m.severity(pdInc.Urgency)
+}
+
+// severityFilter selects incidents for Query's Severities by the severity
+// convertPDIncident would report. The server-side filters it derives only
+// narrow the candidates; match decides.
+type severityFilter struct {
+	mappings    Mappings
+	severities  map[string]bool
+	priorityIDs map[string]bool // priorities whose severity was requested
+	urgencies   map[string]bool // urgencies whose severity was requested
+}
+
+// newSeverityFilter resolves the requested severities to the priorities and
+// urgencies that produce them. An account without priorities (404 from
+// /priorities) filters on urgency alone.
+func (p *PagerDutyProvider) newSeverityFilter(ctx context.Context, severities []string) (*severityFilter, error) {
+	if len(severities) == 0 {
+		return nil, nil
+	}
+	f := &severityFilter{
+		mappings:    p.cfg.Mappings,
+		severities:  map[string]bool{},
+		priorityIDs: map[string]bool{},
+		urgencies:   map[string]bool{},
+	}
+	for _, sev := range severities {
+		f.severities[strings.ToLower(sev)] = true
+	}
+
+	priorities, err := common.ListPriorities(ctx, p.api())
+	if err != nil && !common.IsStatus(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("list priorities: %w", err)
+	}
+	for _, sev := range severities {
+		if ref, ok := p.cfg.Mappings.priority(sev); ok {
+			if _, err := resolvePriority(priorities, sev, ref); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, prio := range priorities {
+		if f.severities[p.cfg.Mappings.severityForPriority(prio.ID, prio.Name)] {
+			f.priorityIDs[prio.ID] = true
+		}
+	}
+	for _, u := range pdUrgencies {
+		if f.severities[strings.ToLower(p.cfg.Mappings.severity(u))] {
+			f.urgencies[u] = true
+		}
+	}
+	return f, nil
+}
+
+// empty reports whether no priority or urgency can produce a requested
+// severity, so nothing can match.
+func (f *severityFilter) empty() bool {
+	return len(f.priorityIDs) == 0 && len(f.urgencies) == 0
+}
+
+// apply adds server-side filter parameters when they cannot drop a match.
+// Without a matching urgency only the priorities qualify. Without a matching
+// priority the urgency filter still admits incidents whose priority maps to
+// another severity; match removes those. When both qualify, an incident may
+// match through either, which one request cannot express.
+func (f *severityFilter) apply(params url.Values) {
+	switch {
+	case len(f.urgencies) == 0:
+		for _, id := range sortedKeys(f.priorityIDs) {
+			params.Add("priority_ids[]", id)
+		}
+	case len(f.priorityIDs) == 0:
+		for _, u := range sortedKeys(f.urgencies) {
+			params.Add("urgencies[]", u)
+		}
+	}
+}
+
+// exact reports whether the parameters added by apply select exactly the
+// matching incidents, so no candidate is dropped locally.
+func (f *severityFilter) exact() bool {
+	return len(f.urgencies) == 0
+}
+
+// match reports whether an incident's severity was requested.
+func (f *severityFilter) match(inc pdIncident) bool {
+	return f.severities[strings.ToLower(f.mappings.incidentSeverity(inc))]
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringMap converts a config object into a map with lower-cased keys and
+// trimmed values. Non-string values are ignored.
+func stringMap(v any) map[string]string {
+	var out map[string]string
+	switch m := v.(type) {
+	case map[string]any:
+		out = make(map[string]string, len(m))
+		for k, val := range m {
+			if s, ok := val.(string); ok {
+				out[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(s)
+			}
+		}
+	case map[string]string:
+		out = make(map[string]string, len(m))
+		for k, s := range m {
+			out[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(s)
+		}
+	}
+	return out
+}
+
+func checkValues(name string, table map[string]string, allowed []string) error {
+	for k, v := range table {
+		if !slices.Contains(allowed, strings.ToLower(v)) {
+			return fmt.Errorf("pagerduty %s[%q] = %q is invalid, must be one of %s", name, k, v, strings.Join(allowed, ", "))
+		}
+		table[k] = strings.ToLower(v)
+	}
+	return nil
+}
+
+func checkKeys(name string, table map[string]string, allowed []string) error {
+	for k := range table {
+		if !slices.Contains(allowed, k) {
+			return fmt.Errorf("pagerduty %s key %q is invalid, must be one of %s", name, k, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestMappingsFromConfig(t *testing.T) {
+	cfg := parseConfig(map[string]any{
+		"severityToUrgency":  map[string]any{"High": "LOW", "sev5": "low"},
+		"urgencyToSeverity":  map[string]any{"high": "sev1", "low": "sev3"},
+		"severityToPriority": map[string]any{"critical": "P1"},
+		"statusToPD":         map[string]any{"mitigated": "acknowledged"},
+		"pdStatusToStatus":   map[string]any{"acknowledged": "investigating"},
+	})
+	if err := cfg.Mappings.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	m := cfg.Mappings
+
+	tests := []struct {
+		name, got, want string
+	}{
+		{"urgency(high)", m.urgency("high"), "low"},
+		{"urgency(sev5)", m.urgency("sev5"), "low"},
+		{"urgency(critical) falls back", m.urgency("critical"), "high"},
+		{"severity(high)", m.severity("high"), "sev1"},
+		{"pdStatus(mitigated)", m.pdStatus("mitigated"), "acknowledged"},
+		{"pdStatus(closed) falls back", m.pdStatus("closed"), "resolved"},
+		{"status(acknowledged)", m.status("acknowledged"), "investigating"},
+		{"status(triggered) falls back", m.status("triggered"), "open"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+	if v, ok := m.priority("Critical"); !ok || v != "P1" {
+		t.Errorf("priority(Critical) = %q, %v, want P1", v, ok)
+	}
+}
+
+func TestNewValidatesMappings(t *testing.T) {
+	base := func() map[string]any {
+		return map[string]any{
+			"apiToken":  "token",
+			"serviceID": "PXXXXXX",
+			"fromEmail": "user@example.com",
+		}
+	}
+	invalid := []map[string]any{
+		{"severityToUrgency": map[string]any{"critical": "urgent"}},
+		{"urgencyToSeverity": map[string]any{"medium": "sev2"}},
+		{"statusToPD": map[string]any{"open": "new"}},
+		{"pdStatusToStatus": map[string]any{"closed": "resolved"}},
+		{"severityToPriority": map[string]any{"critical": ""}},
+	}
+	for _, extra := range invalid {
+		cfg := base()
+		for k, v := range extra {
+			cfg[k] = v
+		}
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%v) expected error", extra)
+		}
+	}
+	if _, err := New(base()); err != nil {
+		t.Errorf("New() with default mappings error = %v", err)
+	}
+}
+
+func TestCreateUsesConfiguredMappings(t *testing.T) {
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/priorities":
+			json.NewEncoder(w).Encode(map[string]any{
+				"priorities": []map[string]any{{"id": "PPRIO1", "name": "P1"}, {"id": "PPRIO2", "name": "P2"}},
+			})
+		case r.URL.Path == "/incidents" && r.Method == "POST":
+			json.NewDecoder(r.Body).Decode(&sent)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]any{
+				"incident": map[string]any{"id": "PNEW", "status": "acknowledged", "urgency": "low"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg: Config{
+			Source:    "pagerduty",
+			APIToken:  "test-token",
+			APIURL:    server.URL,
+			ServiceID: "PXXXXXX",
+			FromEmail: "user@example.com",
+			Mappings: Mappings{
+				SeverityToUrgency:  map[string]string{"high": "low"},
+				UrgencyToSeverity:  map[string]string{"low": "high"},
+				SeverityToPriority: map[string]string{"high": "p2"},
+				PDStatusToStatus:   map[string]string{"acknowledged": "investigating"},
+			},
+		},
+		client: &http.Client{},
+	}
+
+	inc, err := p.Create(context.Background(), schema.CreateIncidentInput{Title: "Disk full", Severity: "high"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	incident := sent["incident"].(map[string]any)
+	if incident["urgency"] != "low" {
+		t.Errorf("urgency = %v, want low", incident["urgency"])
+	}
+	if prio, _ := incident["priority"].(map[string]any); prio["id"] != "PPRIO2" || prio["type"] != "priority_reference" {
+		t.Errorf("priority = %v, want PPRIO2 reference", incident["priority"])
+	}
+	if inc.Severity != "high" || inc.Status != "investigating" {
+		t.Errorf("got severity %q status %q, want high/investigating", inc.Severity, inc.Status)
+	}
+}
+
+func TestConvertPrefersPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		mappings Mappings
+		priority *pdPriority
+		want     string
+	}{
+		{"no priority uses urgency", Mappings{}, nil, "critical"},
+		{"built-in priority table", Mappings{}, &pdPriority{ID: "PPRIO2", Summary: "P2"}, "high"},
+		{"configured priority by ID", Mappings{SeverityToPriority: map[string]string{"sev2": "PPRIO2"}}, &pdPriority{ID: "PPRIO2", Name: "Major"}, "sev2"},
+		{"unknown priority falls back to urgency", Mappings{}, &pdPriority{ID: "PCUSTOM", Name: "Custom"}, "critical"},
+	}
+	for _, tt := range tests {
+		inc := convertPDIncident(pdIncident{ID: "P1", Urgency: "high", Priority: tt.priority}, Config{Source: "pagerduty", Mappings: tt.mappings})
+		if inc.Severity != tt.want {
+			t.Errorf("%s: Severity = %q, want %q", tt.name, inc.Severity, tt.want)
+		}
+		if inc.Metadata["urgency"] != "high" {
+			t.Errorf("%s: metadata urgency = %v, want high", tt.name, inc.Metadata["urgency"])
+		}
+		if tt.priority != nil {
+			prio, _ := inc.Metadata["priority"].(map[string]string)
+			if prio["id"] != tt.priority.ID {
+				t.Errorf("%s: metadata priority = %v", tt.name, inc.Metadata["priority"])
+			}
+		}
+	}
+}
+
+func TestQuerySeveritiesUsePriorities(t *testing.T) {
+	var lastQuery map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/priorities":
+			json.NewEncoder(w).Encode(map[string]any{
+				"priorities": []map[string]any{{"id": "PPRIO1", "name": "P1"}, {"id": "PPRIO2", "name": "P2"}},
+			})
+		case "/incidents":
+			lastQuery = r.URL.Query()
+			json.NewEncoder(w).Encode(map[string]any{
+				"incidents": []map[string]any{
+					{"id": "PA", "urgency": "high", "priority": map[string]any{"id": "PPRIO1", "summary": "P1"}},
+					{"id": "PB", "urgency": "high", "priority": map[string]any{"id": "PPRIO2", "summary": "P2"}},
+					{"id": "PC", "urgency": "low"},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg: Config{
+			Source:   "pagerduty",
+			APIToken: "test-token",
+			APIURL:   server.URL,
+			Mappings: Mappings{SeverityToPriority: map[string]string{"critical": "P1", "high": "P2"}},
+		},
+		client: &http.Client{},
+	}
+	ctx := context.Background()
+
+	t.Run("mapped severities", func(t *testing.T) {
+		incidents, err := p.Query(ctx, schema.IncidentQuery{Severities: []string{"high"}})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if got := lastQuery["priority_ids[]"]; len(got) != 1 || got[0] != "PPRIO2" {
+			t.Errorf("priority_ids[] = %v, want [PPRIO2]", got)
+		}
+		if len(lastQuery["urgencies[]"]) != 0 {
+			t.Errorf("unexpected urgencies[] %v", lastQuery["urgencies[]"])
+		}
+		if len(incidents) != 1 || incidents[0].ID != "PB" || incidents[0].Severity != "high" {
+			t.Errorf("got %+v, want only PB with severity high", incidents)
+		}
+	})
+
+	t.Run("mixed severities", func(t *testing.T) {
+		incidents, err := p.Query(ctx, schema.IncidentQuery{Severities: []string{"critical", "medium"}})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if len(lastQuery["priority_ids[]"]) != 0 || len(lastQuery["urgencies[]"]) != 0 {
+			t.Errorf("expected client-side filtering only, got %v", lastQuery)
+		}
+		if len(incidents) != 2 || incidents[0].ID != "PA" || incidents[1].ID != "PC" {
+			t.Errorf("got %+v, want PA and PC", incidents)
+		}
+	})
+
+	t.Run("unreachable severity", func(t *testing.T) {
+		lastQuery = nil
+		incidents, err := p.Query(ctx, schema.IncidentQuery{Severities: []string{"sev5"}})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if lastQuery != nil || len(incidents) != 0 {
+			t.Errorf("expected no incident request, got %v / %+v", lastQuery, incidents)
+		}
+	})
+}
+
+func TestQuerySeveritiesMatchReportedSeverity(t *testing.T) {
+	var lastQuery map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/priorities":
+			json.NewEncoder(w).Encode(map[string]any{
+				"priorities": []map[string]any{{"id": "PPRIO1", "name": "P1"}, {"id": "PPRIO2", "name": "P2"}},
+			})
+		case "/incidents":
+			lastQuery = r.URL.Query()
+			json.NewEncoder(w).Encode(map[string]any{
+				"incidents": []map[string]any{
+					{"id": "PA", "urgency": "high", "priority": map[string]any{"id": "PPRIO2", "summary": "P2"}},
+					{"id": "PB", "urgency": "high"},
+					{"id": "PC", "urgency": "low", "priority": map[string]any{"id": "PPRIO1", "summary": "P1"}},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{Source: "pagerduty", APIToken: "test-token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+
+	// A P2 incident reports "high" even though its urgency is high, and a
+	// low-urgency P1 incident reports "critical".
+	incidents, err := p.Query(context.Background(), schema.IncidentQuery{Severities: []string{"critical"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(lastQuery["urgencies[]"]) != 0 || len(lastQuery["priority_ids[]"]) != 0 {
+		t.Errorf("expected client-side filtering only, got %v", lastQuery)
+	}
+	if len(incidents) != 2 || incidents[0].ID != "PB" || incidents[1].ID != "PC" {
+		t.Fatalf("got %+v, want PB and PC", incidents)
+	}
+	for _, inc := range incidents {
+		if inc.Severity != "critical" {
+			t.Errorf("incident %s severity = %q, want critical", inc.ID, inc.Severity)
+		}
+	}
+
+	incidents, err = p.Query(context.Background(), schema.IncidentQuery{Severities: []string{"high"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if got := lastQuery["priority_ids[]"]; len(got) != 1 || got[0] != "PPRIO2" {
+		t.Errorf("priority_ids[] = %v, want [PPRIO2]", got)
+	}
+	if len(incidents) != 1 || incidents[0].ID != "PA" {
+		t.Errorf("got %+v, want only PA", incidents)
+	}
+}
+
+func TestQuerySeveritiesBoundsLocalFiltering(t *testing.T) {
+	var limits []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/priorities":
+			json.NewEncoder(w).Encode(map[string]any{
+				"priorities": []map[string]any{{"id": "PPRIO1", "name": "P1"}, {"id": "PPRIO2", "name": "P2"}},
+			})
+		case "/incidents":
+			query := r.URL.Query()
+			limits = append(limits, query.Get("limit"))
+			offset, _ := strconv.Atoi(query.Get("offset"))
+			limit, _ := strconv.Atoi(query.Get("limit"))
+			end := min(offset+limit, 300)
+			var incidents []map[string]any
+			for i := offset; i < end; i++ {
+				incidents = append(incidents, map[string]any{
+					"id":       fmt.Sprintf("P%d", i),
+					"urgency":  "high",
+					"priority": map[string]any{"id": "PPRIO2", "summary": "P2"},
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"incidents": incidents, "more": end < 300})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{Source: "pagerduty", APIToken: "test-token", APIURL: server.URL, SearchMaxScan: 150},
+		client: &http.Client{},
+	}
+
+	// Both P1 and high urgency produce "critical", so nothing is filtered by
+	// PagerDuty and every P2 incident is rejected locally.
+	incidents, err := p.Query(context.Background(), schema.IncidentQuery{Severities: []string{"critical"}, Limit: 1})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(incidents) != 0 {
+		t.Errorf("expected no incidents, got %d", len(incidents))
+	}
+	if want := []string{"100", "50"}; !reflect.DeepEqual(limits, want) {
+		t.Errorf("page sizes = %v, want %v", limits, want)
+	}
+}
@@ -1,14 +1,15 @@
 package incident
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,7 +27,22 @@ const (
 	RequiresCore   = ">=0.1.0"
 )
 
-var errNotFound = errors.New("incident not found")
+// maxServiceIDsPerRequest caps the service_ids[] sent in one GET /incidents
+// so the URL stays well within common length limits.
+const maxServiceIDsPerRequest = 100
+
+// maxPageSize is the largest page size accepted by the PagerDuty list endpoints.
+const maxPageSize = 100
+
+// maxPaginationOffset is the largest offset+limit PagerDuty's classic
+// pagination accepts; deeper pages are rejected with 400.
+const maxPaginationOffset = 10000
+
+// Create modes select how Create opens incidents.
+const (
+	CreateModeREST   = "rest"   // POST /incidents on the REST API
+	CreateModeEvents = "events" // trigger event on the Events API v2
+)
 
 // Config captures decrypted configuration from OpsOrch Core.
 type Config struct {
@@ -36,12 +52,27 @@ type Config struct {
 	APIURL          string
 	ServiceID       string // PagerDuty service ID for creating incidents
 	FromEmail       string // Email address of a valid PagerDuty user
+	Retry           common.RetryPolicy
+	// RateLimitPerMinute caps outgoing requests per API token; 0 disables it.
+	RateLimitPerMinute int
+	Lookup             common.LookupOptions // name matching for Scope lookups
+	CreateMode         string               // CreateModeREST (default) or CreateModeEvents
+	RoutingKey         string               // Events API v2 integration key, required in events mode
+	EventsURL          string               // Events API base URL
+	Mappings           Mappings
+	// SearchMaxResults caps free-text Query results; 0 uses the default.
+	SearchMaxResults int
+	// SearchMaxScan caps the incidents fetched for a free-text Query; 0 uses
+	// the default.
+	SearchMaxScan int
+	Environments  common.Environments
 }
 
 // PagerDutyProvider integrates with PagerDuty REST API v2.
 type PagerDutyProvider struct {
-	cfg    Config
-	client *http.Client
+	cfg     Config
+	client  *http.Client
+	limiter *common.RateLimiter
 }
 
 // New constructs the provider from decrypted config.
@@ -53,15 +84,39 @@ func New(cfg map[string]any) (coreincident.Provider, error) {
 	if parsed.APIURL == "" {
 		return nil, errors.New("pagerduty apiURL is required")
 	}
-	if parsed.ServiceID == "" {
-		return nil, errors.New("pagerduty serviceID is required")
+	if err := parsed.Lookup.Validate(); err != nil {
+		return nil, err
+	}
+	switch parsed.CreateMode {
+	case CreateModeREST:
+		if parsed.ServiceID == "" {
+			return nil, errors.New("pagerduty serviceID is required")
+		}
+	case CreateModeEvents:
+		if parsed.RoutingKey == "" {
+			return nil, errors.New("pagerduty routingKey is required when createMode is events")
+		}
+	default:
+		return nil, fmt.Errorf("pagerduty createMode %q is not supported", parsed.CreateMode)
 	}
 	if parsed.FromEmail == "" {
 		return nil, errors.New("pagerduty fromEmail is required")
 	}
+	if err := parsed.Mappings.validate(); err != nil {
+		return nil, err
+	}
+	if err := parsed.Environments.Compile(); err != nil {
+		return nil, err
+	}
+	parsed.Lookup.Cache = common.SharedLookupCache(parsed.APIURL, parsed.APIToken, parsed.Lookup.CacheConfig)
+	var limiter *common.RateLimiter
+	if parsed.RateLimitPerMinute > 0 {
+		limiter = common.SharedRateLimiter(parsed.APIToken, parsed.RateLimitPerMinute)
+	}
 	return &PagerDutyProvider{
-		cfg:    parsed,
-		client: &http.Client{Timeout: 30 * time.Second},
+		cfg:     parsed,
+		client:  common.NewHTTPClient(limiter),
+		limiter: limiter,
 	}, nil
 }
 
@@ -71,41 +126,29 @@ func init() {
 
 // Get returns a single incident by ID from PagerDuty.
 func (p *PagerDutyProvider) Get(ctx context.Context, id string) (schema.Incident, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", p.cfg.APIURL+"/incidents/"+id, nil)
-	if err != nil {
-		return schema.Incident{}, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Token token="+p.cfg.APIToken)
-	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return schema.Incident{}, fmt.Errorf("execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
-		return schema.Incident{}, errNotFound
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return schema.Incident{}, fmt.Errorf("pagerduty api error: %d %s", resp.StatusCode, string(bodyBytes))
-	}
-
 	var result struct {
 		Incident pdIncident `json:"incident"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return schema.Incident{}, fmt.Errorf("decode response: %w", err)
+	if err := p.api().Get(ctx, "/incidents/"+id, nil, &result); err != nil {
+		return schema.Incident{}, wrapNotFound(id, err)
 	}
 
-	return convertPDIncident(result.Incident, p.cfg.Source), nil
+	return convertPDIncident(result.Incident, p.cfg), nil
 }
 
-// Create creates a new incident in PagerDuty.
+// Create creates a new incident in PagerDuty. In events mode the incident is
+// opened through the Events API v2 instead of the REST API.
+//
+// Every incident is created with an incident_key (see incidentKey), so a
+// retried create that PagerDuty rejects as a duplicate returns the incident
+// opened by the first attempt instead of an error.
 func (p *PagerDutyProvider) Create(ctx context.Context, in schema.CreateIncidentInput) (schema.Incident, error) {
+	if p.cfg.CreateMode == CreateModeEvents {
+		return p.createViaEvents(ctx, in)
+	}
+
+	key := p.incidentKey(in)
+	severity := defaultString(in.Severity, p.cfg.DefaultSeverity)
 	payload := map[string]any{
 		"incident": map[string]any{
 			"type":  "incident",
@@ -114,10 +157,19 @@ func (p *PagerDutyProvider) Create(ctx context.Context, in schema.CreateIncident
 				"id":   p.cfg.ServiceID,
 				"type": "service_reference",
 			},
-			"urgency": mapSeverityToUrgency(defaultString(in.Severity, p.cfg.DefaultSeverity)),
+			"urgency":      p.cfg.Mappings.urgency(severity),
+			"incident_key": key,
 		},
 	}
 
+	priorityID, err := p.priorityID(ctx, severity)
+	if err != nil {
+		return schema.Incident{}, err
+	}
+	if priorityID != "" {
+		payload["incident"].(map[string]any)["priority"] = reference(priorityID, "priority_reference")
+	}
+
 	// Add description if provided
 	if in.Description != "" {
 		payload["incident"].(map[string]any)["body"] = map[string]any{
@@ -126,146 +178,178 @@ func (p *PagerDutyProvider) Create(ctx context.Context, in schema.CreateIncident
 		}
 	}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return schema.Incident{}, fmt.Errorf("marshal create payload: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", p.cfg.APIURL+"/incidents", bytes.NewReader(body))
-	if err != nil {
-		return schema.Incident{}, fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Token token="+p.cfg.APIToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
-	req.Header.Set("From", p.cfg.FromEmail)
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return schema.Incident{}, fmt.Errorf("execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return schema.Incident{}, fmt.Errorf("pagerduty api error: %d %s", resp.StatusCode, string(bodyBytes))
-	}
-
 	var result struct {
 		Incident pdIncident `json:"incident"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return schema.Incident{}, fmt.Errorf("decode response: %w", err)
+	if err := p.api().Post(ctx, "/incidents", payload, &result); err != nil {
+		if !isDedupConflict(err) {
+			return schema.Incident{}, err
+		}
+		existing, findErr := p.findOpenByIncidentKey(ctx, key)
+		if findErr != nil {
+			return schema.Incident{}, fmt.Errorf("lookup incident for dedup key %q: %w", key, findErr)
+		}
+		if existing == nil {
+			return schema.Incident{}, err
+		}
+		return convertPDIncident(*existing, p.cfg), nil
 	}
 
-	return convertPDIncident(result.Incident, p.cfg.Source), nil
+	return convertPDIncident(result.Incident, p.cfg), nil
 }
 
-// Update modifies an incident in PagerDuty.
-func (p *PagerDutyProvider) Update(ctx context.Context, id string, in schema.UpdateIncidentInput) (schema.Incident, error) {
-	payload := map[string]any{
-		"incident": map[string]any{
-			"type": "incident",
-		},
+// incidentKey returns the dedup key for a create request. An explicit key in
+// Metadata (idempotency_key, dedup_key or incident_key) wins; otherwise the key
+// is derived from the title and service so retries of the same request map to
+// the same PagerDuty incident while it is open.
+func (p *PagerDutyProvider) incidentKey(in schema.CreateIncidentInput) string {
+	for _, k := range []string{"idempotency_key", "dedup_key", "incident_key"} {
+		if v := metadataString(in.Metadata, k); v != "" {
+			return v
+		}
 	}
+	sum := sha256.Sum256([]byte(in.Title + "\x00" + defaultString(in.Service, p.cfg.ServiceID)))
+	return "opsorch-" + hex.EncodeToString(sum[:16])
+}
 
-	if in.Title != nil {
-		payload["incident"].(map[string]any)["title"] = *in.Title
+// findOpenByIncidentKey returns the open incident with the given key on the
+// configured service, or nil if there is none.
+func (p *PagerDutyProvider) findOpenByIncidentKey(ctx context.Context, key string) (*pdIncident, error) {
+	params := url.Values{}
+	params.Set("incident_key", key)
+	params.Add("statuses[]", "triggered")
+	params.Add("statuses[]", "acknowledged")
+	if p.cfg.ServiceID != "" {
+		params.Add("service_ids[]", p.cfg.ServiceID)
 	}
+	params.Set("limit", "1")
 
-	if in.Status != nil {
-		payload["incident"].(map[string]any)["status"] = mapStatusToPD(*in.Status)
+	page, _, err := p.queryPage(ctx, params)
+	if err != nil || len(page) == 0 {
+		return nil, err
 	}
+	return &page[0], nil
+}
 
-	if in.Severity != nil {
-		payload["incident"].(map[string]any)["urgency"] = mapSeverityToUrgency(*in.Severity)
+// isDedupConflict reports whether PagerDuty rejected a create because an open
+// incident with the same incident_key already exists.
+func isDedupConflict(err error) bool {
+	var apiErr *common.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
 	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return schema.Incident{}, fmt.Errorf("marshal update payload: %w", err)
+	for _, msg := range append([]string{apiErr.Message, apiErr.Body}, apiErr.Errors...) {
+		if strings.Contains(strings.ToLower(msg), "matching dedup key") {
+			return true
+		}
 	}
+	return false
+}
 
-	req, err := http.NewRequestWithContext(ctx, "PUT", p.cfg.APIURL+"/incidents/"+id, bytes.NewReader(body))
+// Update modifies an incident in PagerDuty. See buildUpdatePayload for the
+// metadata keys that map to assignments, escalation, priority, resolution and
+// service changes.
+func (p *PagerDutyProvider) Update(ctx context.Context, id string, in schema.UpdateIncidentInput) (schema.Incident, error) {
+	payload, err := p.buildUpdatePayload(ctx, in)
 	if err != nil {
-		return schema.Incident{}, fmt.Errorf("create request: %w", err)
+		return schema.Incident{}, err
 	}
 
-	req.Header.Set("Authorization", "Token token="+p.cfg.APIToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
-	req.Header.Set("From", p.cfg.FromEmail)
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return schema.Incident{}, fmt.Errorf("execute request: %w", err)
+	var result struct {
+		Incident pdIncident `json:"incident"`
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
-		return schema.Incident{}, errNotFound
+	if err := p.api().Put(ctx, "/incidents/"+id, payload, &result); err != nil {
+		return schema.Incident{}, wrapNotFound(id, err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return schema.Incident{}, fmt.Errorf("pagerduty api error: %d %s", resp.StatusCode, string(bodyBytes))
+	return convertPDIncident(result.Incident, p.cfg), nil
+}
+
+// Merge merges the source incidents into the target incident id and returns
+// the target. PagerDuty moves the sources' alerts to the target and resolves
+// the sources.
+func (p *PagerDutyProvider) Merge(ctx context.Context, id string, sourceIDs []string) (schema.Incident, error) {
+	if id == "" {
+		return schema.Incident{}, common.Invalid(errors.New("target incident id is required"))
+	}
+	if len(sourceIDs) == 0 {
+		return schema.Incident{}, common.Invalid(errors.New("at least one source incident id is required"))
+	}
+	sources := make([]map[string]string, 0, len(sourceIDs))
+	for _, sourceID := range sourceIDs {
+		sourceID = strings.TrimSpace(sourceID)
+		if sourceID == "" || sourceID == id {
+			return schema.Incident{}, common.Invalid(fmt.Errorf("invalid source incident id %q for target %s", sourceID, id))
+		}
+		sources = append(sources, reference(sourceID, "incident_reference"))
 	}
 
 	var result struct {
 		Incident pdIncident `json:"incident"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return schema.Incident{}, fmt.Errorf("decode response: %w", err)
+	if err := p.api().Put(ctx, "/incidents/"+id+"/merge", map[string]any{"source_incidents": sources}, &result); err != nil {
+		return schema.Incident{}, wrapNotFound(id, err)
 	}
 
-	return convertPDIncident(result.Incident, p.cfg.Source), nil
+	return convertPDIncident(result.Incident, p.cfg), nil
 }
 
-// Query searches for incidents in PagerDuty.
+// Query searches for incidents in PagerDuty. Results are paginated using
+// PagerDuty's offset/more fields until the requested limit is reached or all
+// matching incidents have been fetched. Metadata since/until/date_range select
+// the time range; see queryWindows. A free-text q.Query is matched locally
+// against the fetched candidates; see searchTerms. Metadata sort_by orders the
+// results; see sortIncidents.
 func (p *PagerDutyProvider) Query(ctx context.Context, q schema.IncidentQuery) ([]schema.Incident, error) {
 	params := url.Values{}
 
-	if q.Limit > 0 {
-		params.Set("limit", fmt.Sprintf("%d", q.Limit))
-	} else {
-		params.Set("limit", "100")
-	}
-
 	if len(q.Statuses) > 0 {
 		for _, status := range q.Statuses {
-			params.Add("statuses[]", mapStatusToPD(status))
+			params.Add("statuses[]", p.cfg.Mappings.pdStatus(status))
 		}
 	}
 
-	if len(q.Severities) > 0 {
-		for _, severity := range q.Severities {
-			params.Add("urgencies[]", mapSeverityToUrgency(severity))
+	severities, err := p.newSeverityFilter(ctx, q.Severities)
+	if err != nil {
+		return nil, err
+	}
+	if severities != nil {
+		if severities.empty() {
+			return []schema.Incident{}, nil
 		}
+		severities.apply(params)
 	}
 
 	// Translate Scope fields to PagerDuty IDs via lookups
 	if q.Scope.Service != "" {
-		serviceIDs, err := common.LookupServiceIDsByName(ctx, p.client, p.cfg.APIURL, p.cfg.APIToken, q.Scope.Service)
+		services, err := common.LookupServices(ctx, p.api(), q.Scope.Service, p.cfg.Lookup)
 		if err != nil {
 			return nil, fmt.Errorf("lookup service by name %q: %w", q.Scope.Service, err)
 		}
-		for _, id := range serviceIDs {
+		// An unknown name must not widen the query to the whole account.
+		if len(services) == 0 {
+			return []schema.Incident{}, nil
+		}
+		for _, id := range services.IDs() {
 			params.Add("service_ids[]", id)
 		}
 	}
 
 	if q.Scope.Team != "" {
-		teamIDs, err := common.LookupTeamIDsByName(ctx, p.client, p.cfg.APIURL, p.cfg.APIToken, q.Scope.Team)
+		teams, err := common.LookupTeams(ctx, p.api(), q.Scope.Team, p.cfg.Lookup)
 		if err != nil {
 			return nil, fmt.Errorf("lookup team by name %q: %w", q.Scope.Team, err)
 		}
-		for _, id := range teamIDs {
+		if len(teams) == 0 {
+			return []schema.Incident{}, nil
+		}
+		for _, id := range teams.IDs() {
 			params.Add("team_ids[]", id)
 		}
 	}
 
-	// Map known metadata fields to API filters
+	// Map known metadata fields to API filters. Service IDs are added before
+	// the environment is resolved so they are intersected with it too.
 	if len(q.Metadata) > 0 {
 		if v, ok := q.Metadata["service_id"].(string); ok && v != "" {
 			params.Add("service_ids[]", v)
@@ -278,115 +362,234 @@ func (p *PagerDutyProvider) Query(ctx context.Context, q schema.IncidentQuery) (
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", p.cfg.APIURL+"/incidents?"+params.Encode(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+	if q.Scope.Environment != "" && p.cfg.Environments.Enabled() {
+		ids, err := p.cfg.Environments.ServiceIDsInEnvironment(ctx, p.api(), q.Scope.Environment, p.cfg.Lookup.Cache)
+		if err != nil {
+			return nil, fmt.Errorf("resolve environment %q: %w", q.Scope.Environment, err)
+		}
+		if scoped := params["service_ids[]"]; len(scoped) > 0 {
+			ids = slices.DeleteFunc(ids, func(id string) bool { return !slices.Contains(scoped, id) })
+		}
+		if len(ids) == 0 {
+			return []schema.Incident{}, nil
+		}
+		params["service_ids[]"] = ids
 	}
 
-	req.Header.Set("Authorization", "Token token="+p.cfg.APIToken)
-	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
-
-	resp, err := p.client.Do(req)
+	windows, err := queryWindows(q.Metadata, time.Now())
 	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("pagerduty api error: %d %s", resp.StatusCode, string(bodyBytes))
+	order, err := common.ParseSort(q.Metadata, sortFields)
+	if err != nil {
+		return nil, err
 	}
-
-	var result struct {
-		Incidents []pdIncident `json:"incidents"`
+	if serverSide(order) {
+		params.Set("sort_by", order.Param())
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	if order.Desc && chronological(order) {
+		slices.Reverse(windows)
 	}
 
-	incidents := make([]schema.Incident, len(result.Incidents))
-	for i, pdInc := range result.Incidents {
-		incidents[i] = convertPDIncident(pdInc, p.cfg.Source)
+	// Large environments can resolve to hundreds of services; their IDs are
+	// sent in several requests to keep the URL short.
+	serviceChunks := [][]string{nil}
+	if ids := params["service_ids[]"]; len(ids) > maxServiceIDsPerRequest {
+		serviceChunks = serviceChunks[:0]
+		for len(ids) > maxServiceIDsPerRequest {
+			serviceChunks = append(serviceChunks, ids[:maxServiceIDsPerRequest])
+			ids = ids[maxServiceIDsPerRequest:]
+		}
+		serviceChunks = append(serviceChunks, ids)
 	}
 
-	return incidents, nil
-}
-
-// GetTimeline returns the log entries (timeline) for an incident from PagerDuty.
-func (p *PagerDutyProvider) GetTimeline(ctx context.Context, id string) ([]schema.TimelineEntry, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", p.cfg.APIURL+"/incidents/"+id+"/log_entries", nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+	limit := q.Limit
+	c := &collector{
+		seen: map[string]bool{},
+		out:  []schema.Incident{},
 	}
-
-	req.Header.Set("Authorization", "Token token="+p.cfg.APIToken)
-	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
+	var terms searchTerms
+	if q.Query != "" {
+		terms = parseSearch(q.Query)
+		maxResults := p.cfg.searchMaxResults()
+		if limit <= 0 || limit > maxResults {
+			limit = maxResults
+		}
+		c.maxScan = p.cfg.searchMaxScan()
+	}
+	c.limit = limit
+	if limit > 0 && !order.IsZero() && (!serverSide(order) || len(serviceChunks) > 1 || (len(windows) > 1 && !chronological(order))) {
+		// The first Limit results fetched are not the first in this order:
+		// PagerDuty cannot sort by the field, or sorts each chunk and window
+		// on its own. Collect everything up to the scan cap, then sort and
+		// truncate.
+		c.limit = 0
+		if c.maxScan == 0 {
+			c.maxScan = p.cfg.searchMaxScan()
+		}
+	}
+	if severities != nil && !severities.exact() && c.maxScan == 0 {
+		// Severities are partly matched here, so the result count says
+		// little about how many candidates are needed.
+		c.maxScan = p.cfg.searchMaxScan()
+	}
+	c.accept = func(pdInc pdIncident) bool {
+		if severities != nil && !severities.match(pdInc) {
+			return false
+		}
+		return len(terms) == 0 || terms.match(pdInc)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("pagerduty api error: %d %s", resp.StatusCode, string(bodyBytes))
+collect:
+	for _, w := range windows {
+		w.apply(params)
+		for _, chunk := range serviceChunks {
+			if chunk != nil {
+				params["service_ids[]"] = chunk
+			}
+			if err := p.collect(ctx, params, c); err != nil {
+				return nil, err
+			}
+			if c.done() {
+				break collect
+			}
+		}
 	}
 
-	var result struct {
-		LogEntries []pdLogEntry `json:"log_entries"`
+	if !order.IsZero() {
+		sortIncidents(c.out, order)
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	if limit > 0 && len(c.out) > limit {
+		c.out = c.out[:limit]
 	}
-
-	entries := make([]schema.TimelineEntry, len(result.LogEntries))
-	for i, le := range result.LogEntries {
-		entries[i] = convertPDLogEntry(le, id)
+	if c.truncated {
+		for _, inc := range c.out {
+			inc.Metadata["results_truncated"] = true
+		}
 	}
+	if q.Scope.Environment != "" && p.cfg.Environments.Enabled() {
+		// Tag-derived environments are not known to convertPDIncident.
+		for _, inc := range c.out {
+			if _, ok := inc.Metadata["environment"]; !ok {
+				inc.Metadata["environment"] = q.Scope.Environment
+			}
+		}
+	}
+	return c.out, nil
+}
 
-	return entries, nil
+// collector accumulates Query results across pages and time windows.
+type collector struct {
+	limit     int // maximum results, 0 for no limit
+	maxScan   int // maximum incidents fetched, 0 for no limit
+	scanned   int
+	truncated bool // PagerDuty's pagination ceiling cut a window short
+	accept    func(pdIncident) bool
+	seen      map[string]bool
+	out       []schema.Incident
 }
 
-// AppendTimeline adds a note to an incident in PagerDuty.
-func (p *PagerDutyProvider) AppendTimeline(ctx context.Context, id string, entry schema.TimelineAppendInput) error {
-	payload := map[string]any{
-		"note": map[string]any{
-			"content": entry.Body,
-		},
-	}
+// done reports whether the result limit or the scan limit has been reached.
+func (c *collector) done() bool {
+	return (c.limit > 0 && len(c.out) >= c.limit) || (c.maxScan > 0 && c.scanned >= c.maxScan)
+}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("marshal note payload: %w", err)
-	}
+// collect pages through GET /incidents with params and adds accepted
+// incidents that have not been seen yet until the collector is done.
+func (p *PagerDutyProvider) collect(ctx context.Context, params url.Values, c *collector) error {
+	offset := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if offset >= maxPaginationOffset {
+			c.truncated = true
+			return nil
+		}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", p.cfg.APIURL+"/incidents/"+id+"/notes", bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("create request: %w", err)
-	}
+		pageSize := maxPageSize
+		if c.maxScan > 0 {
+			// Client-side filtering: the result count says little about how
+			// many candidates are needed, so page by the scan budget.
+			if c.maxScan-c.scanned < pageSize {
+				pageSize = c.maxScan - c.scanned
+			}
+		} else if c.limit > 0 && c.limit-len(c.out) < pageSize {
+			pageSize = c.limit - len(c.out)
+		}
+		pageSize = min(pageSize, maxPaginationOffset-offset)
+		params.Set("limit", strconv.Itoa(pageSize))
+		params.Set("offset", strconv.Itoa(offset))
 
-	req.Header.Set("Authorization", "Token token="+p.cfg.APIToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
-	req.Header.Set("From", p.cfg.FromEmail)
+		page, more, err := p.queryPage(ctx, params)
+		if err != nil {
+			return err
+		}
 
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("execute request: %w", err)
+		for _, pdInc := range page {
+			if c.done() {
+				break
+			}
+			c.scanned++
+			if c.seen[pdInc.ID] || !c.accept(pdInc) {
+				continue
+			}
+			c.seen[pdInc.ID] = true
+			c.out = append(c.out, convertPDIncident(pdInc, p.cfg))
+		}
+		offset += len(page)
+
+		if !more || len(page) == 0 || c.done() {
+			return nil
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode == http.StatusNotFound {
-		return errNotFound
+// queryPage fetches a single page of incidents and reports whether PagerDuty
+// has more results beyond it.
+func (p *PagerDutyProvider) queryPage(ctx context.Context, params url.Values) ([]pdIncident, bool, error) {
+	var result struct {
+		Incidents []pdIncident `json:"incidents"`
+		More      bool         `json:"more"`
+	}
+	if err := p.api().Get(ctx, "/incidents", params, &result); err != nil {
+		return nil, false, err
 	}
 
-	if resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("pagerduty api error: %d %s", resp.StatusCode, string(bodyBytes))
+	return result.Incidents, result.More, nil
+}
+
+// RateLimitBudget reports the client-side request budget when a rate limit is
+// configured.
+func (p *PagerDutyProvider) RateLimitBudget() (common.RateLimitBudget, bool) {
+	if p.limiter == nil {
+		return common.RateLimitBudget{}, false
 	}
+	return p.limiter.Budget(), true
+}
+
+// InvalidateLookupCache drops cached name lookups for kind and name (empty
+// values match everything) and returns how many entries were removed.
+func (p *PagerDutyProvider) InvalidateLookupCache(kind, name string) int {
+	return p.cfg.Lookup.Cache.Invalidate(kind, name)
+}
+
+// api returns a PagerDuty client bound to the provider's configuration.
+func (p *PagerDutyProvider) api() *common.Client {
+	c := common.NewClient(p.client, p.cfg.APIURL, p.cfg.APIToken)
+	c.From = p.cfg.FromEmail
+	c.Retry = p.cfg.Retry
+	return c
+}
 
-	return nil
+// wrapNotFound names the incident in 404 errors. The result still matches
+// common.ErrNotFound and the underlying *common.APIError.
+func wrapNotFound(id string, err error) error {
+	if errors.Is(err, common.ErrNotFound) {
+		return fmt.Errorf("incident %s not found: %w", id, err)
+	}
+	return err
 }
 
 func parseConfig(cfg map[string]any) Config {
@@ -394,6 +597,8 @@ func parseConfig(cfg map[string]any) Config {
 		Source:          "pagerduty",
 		DefaultSeverity: "critical",
 		APIURL:          "https://api.pagerduty.com",
+		CreateMode:      CreateModeREST,
+		EventsURL:       "https://events.pagerduty.com",
 	}
 	if v, ok := cfg["source"].(string); ok && v != "" {
 		out.Source = v
@@ -413,78 +618,160 @@ func parseConfig(cfg map[string]any) Config {
 	if v, ok := cfg["fromEmail"].(string); ok {
 		out.FromEmail = strings.TrimSpace(v)
 	}
+	if v, ok := common.IntFromConfig(cfg["rateLimitPerMinute"]); ok && v > 0 {
+		out.RateLimitPerMinute = v
+	}
+	if v, ok := cfg["createMode"].(string); ok && v != "" {
+		out.CreateMode = strings.ToLower(strings.TrimSpace(v))
+	}
+	if v, ok := cfg["routingKey"].(string); ok {
+		out.RoutingKey = strings.TrimSpace(v)
+	}
+	if v, ok := cfg["eventsURL"].(string); ok && v != "" {
+		out.EventsURL = strings.TrimSpace(v)
+	}
+	out.Retry = common.ParseRetryPolicy(cfg)
+	out.Lookup = common.ParseLookupOptions(cfg)
+	out.Mappings = parseMappings(cfg)
+	if v, ok := common.IntFromConfig(cfg["searchMaxResults"]); ok && v > 0 {
+		out.SearchMaxResults = v
+	}
+	if v, ok := common.IntFromConfig(cfg["searchMaxScan"]); ok && v > 0 {
+		out.SearchMaxScan = v
+	}
+	out.Environments = common.ParseEnvironments(cfg)
 	return out
 }
 
+func (c Config) searchMaxResults() int {
+	if c.SearchMaxResults > 0 {
+		return c.SearchMaxResults
+	}
+	return defaultSearchMaxResults
+}
+
+func (c Config) searchMaxScan() int {
+	if c.SearchMaxScan > 0 {
+		return c.SearchMaxScan
+	}
+	return defaultSearchMaxScan
+}
+
 // pdIncident represents a PagerDuty incident from the API.
 type pdIncident struct {
-	ID          string `json:"id"`
-	IncidentKey string `json:"incident_key"`
-	Title       string `json:"title"`
-	Status      string `json:"status"`
-	Urgency     string `json:"urgency"`
-	HTMLURL     string `json:"html_url"`
+	ID          string      `json:"id"`
+	IncidentKey string      `json:"incident_key"`
+	Title       string      `json:"title"`
+	Status      string      `json:"status"`
+	Urgency     string      `json:"urgency"`
+	Priority    *pdPriority `json:"priority"`
+	HTMLURL     string      `json:"html_url"`
 	Service     struct {
 		ID      string `json:"id"`
 		Summary string `json:"summary"`
 		HTMLURL string `json:"html_url"`
 	} `json:"service"`
 	Assignments []struct {
-		Assignee struct {
-			ID      string `json:"id"`
-			Summary string `json:"summary"`
-			HTMLURL string `json:"html_url"`
-		} `json:"assignee"`
+		Assignee pdReference `json:"assignee"`
 	} `json:"assignments"`
+	Acknowledgements []struct {
+		At           string      `json:"at"`
+		Acknowledger pdReference `json:"acknowledger"`
+	} `json:"acknowledgements"`
 	Body struct {
 		Details string `json:"details"`
 	} `json:"body"`
-	LastStatusChangeAt string `json:"last_status_change_at"`
-	CreatedAt          string `json:"created_at"`
-	UpdatedAt          string `json:"updated_at"`
-}
-
-// pdLogEntry represents a PagerDuty log entry.
-type pdLogEntry struct {
-	ID        string `json:"id"`
-	Type      string `json:"type"`
-	Summary   string `json:"summary"`
-	CreatedAt string `json:"created_at"`
-	Agent     *struct {
-		Summary string `json:"summary"`
-	} `json:"agent"`
+	IncidentNumber   int           `json:"incident_number"`
+	EscalationPolicy *pdReference  `json:"escalation_policy"`
+	Teams            []pdReference `json:"teams"`
+	ResolveReason    *struct {
+		Type     string       `json:"type"`
+		Incident *pdReference `json:"incident"`
+	} `json:"resolve_reason"`
+	ConferenceBridge *struct {
+		ConferenceNumber string `json:"conference_number"`
+		ConferenceURL    string `json:"conference_url"`
+	} `json:"conference_bridge"`
+	FirstTriggerLogEntry *pdReference `json:"first_trigger_log_entry"`
+	AlertCounts          *struct {
+		All       int `json:"all"`
+		Triggered int `json:"triggered"`
+		Resolved  int `json:"resolved"`
+	} `json:"alert_counts"`
+	PendingActions []struct {
+		Type string `json:"type"`
+		At   string `json:"at"`
+	} `json:"pending_actions"`
+	LastStatusChangeBy *pdReference `json:"last_status_change_by"`
+	LastStatusChangeAt string       `json:"last_status_change_at"`
+	CreatedAt          string       `json:"created_at"`
+	UpdatedAt          string       `json:"updated_at"`
+}
+
+// pdReference is a PagerDuty object reference embedded in another object.
+type pdReference struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Summary string `json:"summary"`
+	HTMLURL string `json:"html_url"`
+}
+
+// metadata returns the reference in the shape used for incident metadata.
+func (r pdReference) metadata() map[string]string {
+	return map[string]string{
+		"id":       r.ID,
+		"name":     r.Summary,
+		"html_url": r.HTMLURL,
+	}
+}
+
+// pdPriority is the priority reference on a PagerDuty incident.
+type pdPriority struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
 }
 
-func convertPDIncident(pdInc pdIncident, source string) schema.Incident {
+func convertPDIncident(pdInc pdIncident, cfg Config) schema.Incident {
 	inc := schema.Incident{
 		ID:          pdInc.ID,
 		Title:       pdInc.Title,
 		Description: pdInc.Body.Details,
-		Status:      mapPDStatusToOpsOrch(pdInc.Status),
-		Severity:    mapUrgencyToSeverity(pdInc.Urgency),
+		Status:      cfg.Mappings.status(pdInc.Status),
+		Severity:    cfg.Mappings.incidentSeverity(pdInc),
 		Service:     pdInc.Service.Summary,
 		Metadata: map[string]any{
-			"source":                source,
+			"source":                cfg.Source,
 			"incident_key":          pdInc.IncidentKey,
 			"service_id":            pdInc.Service.ID,
 			"service_url":           pdInc.Service.HTMLURL,
 			"html_url":              pdInc.HTMLURL,
 			"last_status_change_at": pdInc.LastStatusChangeAt,
+			"urgency":               pdInc.Urgency,
 		},
 	}
 
+	if pdInc.Priority != nil {
+		inc.Metadata["priority"] = map[string]string{
+			"id":   pdInc.Priority.ID,
+			"name": defaultString(pdInc.Priority.Name, pdInc.Priority.Summary),
+		}
+	}
+
 	if len(pdInc.Assignments) > 0 {
 		assignees := make([]map[string]string, len(pdInc.Assignments))
 		for i, assignment := range pdInc.Assignments {
-			assignees[i] = map[string]string{
-				"id":       assignment.Assignee.ID,
-				"name":     assignment.Assignee.Summary,
-				"html_url": assignment.Assignee.HTMLURL,
-			}
+			assignees[i] = assignment.Assignee.metadata()
 		}
 		inc.Metadata["assignments"] = assignees
 	}
 
+	addIncidentDetails(inc.Metadata, pdInc)
+
+	if env := cfg.Environments.ForService(pdInc.Service.ID, pdInc.Service.Summary, nil, nil); env != "" {
+		inc.Metadata["environment"] = env
+	}
+
 	if createdAt, err := time.Parse(time.RFC3339, pdInc.CreatedAt); err == nil {
 		inc.CreatedAt = createdAt
 	}
@@ -495,28 +782,82 @@ func convertPDIncident(pdInc pdIncident, source string) schema.Incident {
 	return inc
 }
 
-func convertPDLogEntry(le pdLogEntry, incidentID string) schema.TimelineEntry {
-	entry := schema.TimelineEntry{
-		ID:         le.ID,
-		IncidentID: incidentID,
-		Kind:       le.Type,
-		Body:       le.Summary,
-		Metadata: map[string]any{
-			"type": le.Type,
-		},
+// addIncidentDetails copies the optional parts of a PagerDuty incident into
+// metadata. Core has no team field on incidents, so teams are exposed here too.
+func addIncidentDetails(md map[string]any, pdInc pdIncident) {
+	if pdInc.IncidentNumber > 0 {
+		md["incident_number"] = pdInc.IncidentNumber
+	}
+
+	if len(pdInc.Acknowledgements) > 0 {
+		acks := make([]map[string]string, len(pdInc.Acknowledgements))
+		for i, ack := range pdInc.Acknowledgements {
+			acks[i] = ack.Acknowledger.metadata()
+			acks[i]["at"] = ack.At
+		}
+		md["acknowledgements"] = acks
+	}
+
+	if pdInc.EscalationPolicy != nil {
+		md["escalation_policy"] = pdInc.EscalationPolicy.metadata()
 	}
 
-	if at, err := time.Parse(time.RFC3339, le.CreatedAt); err == nil {
-		entry.At = at
+	if len(pdInc.Teams) > 0 {
+		teams := make([]map[string]string, len(pdInc.Teams))
+		for i, team := range pdInc.Teams {
+			teams[i] = team.metadata()
+		}
+		md["teams"] = teams
+	}
+
+	if rr := pdInc.ResolveReason; rr != nil {
+		reason := map[string]string{"type": rr.Type}
+		if rr.Incident != nil {
+			reason["incident_id"] = rr.Incident.ID
+			reason["incident_url"] = rr.Incident.HTMLURL
+		}
+		md["resolve_reason"] = reason
+	}
+
+	if cb := pdInc.ConferenceBridge; cb != nil && (cb.ConferenceNumber != "" || cb.ConferenceURL != "") {
+		md["conference_bridge"] = map[string]string{
+			"conference_number": cb.ConferenceNumber,
+			"conference_url":    cb.ConferenceURL,
+		}
+	}
+
+	if pdInc.FirstTriggerLogEntry != nil {
+		md["first_trigger_log_entry"] = map[string]string{
+			"id":       pdInc.FirstTriggerLogEntry.ID,
+			"summary":  pdInc.FirstTriggerLogEntry.Summary,
+			"html_url": pdInc.FirstTriggerLogEntry.HTMLURL,
+		}
+	}
+
+	if ac := pdInc.AlertCounts; ac != nil {
+		md["alert_counts"] = map[string]int{
+			"all":       ac.All,
+			"triggered": ac.Triggered,
+			"resolved":  ac.Resolved,
+		}
 	}
 
-	if le.Agent != nil {
-		entry.Actor = map[string]any{
-			"name": le.Agent.Summary,
+	if len(pdInc.PendingActions) > 0 {
+		actions := make([]map[string]string, len(pdInc.PendingActions))
+		for i, action := range pdInc.PendingActions {
+			actions[i] = map[string]string{"type": action.Type, "at": action.At}
 		}
+		md["pending_actions"] = actions
 	}
 
-	return entry
+	if by := pdInc.LastStatusChangeBy; by != nil {
+		md["last_status_change_by"] = map[string]string{
+			"id":       by.ID,
+			"type":     by.Type,
+			"name":     by.Summary,
+			"html_url": by.HTMLURL,
+		}
+	}
 }
 
 func defaultString(val string, fallback string) string {
@@ -526,6 +867,9 @@ func defaultString(val string, fallback string) string {
 	return fallback
 }
 
+// The functions below are the built-in mappings used when a value is not in the
+// configured Mappings tables.
+
 // mapSeverityToUrgency maps OpsOrch severity to PagerDuty urgency.
 func mapSeverityToUrgency(severity string) string {
 	switch strings.ToLower(severity) {
@@ -554,6 +898,23 @@ func mapUrgencyToSeverity(urgency string) string {
 	}
 }
 
+// mapPriorityToSeverity maps PagerDuty's standard priority names to OpsOrch
+// severity. Custom priority names return "".
+func mapPriorityToSeverity(name string) string {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "P1":
+		return "critical"
+	case "P2":
+		return "high"
+	case "P3":
+		return "medium"
+	case "P4", "P5":
+		return "low"
+	default:
+		return ""
+	}
+}
+
 // mapStatusToPD maps OpsOrch status to PagerDuty status.
 func mapStatusToPD(status string) string {
 	switch strings.ToLower(status) {
@@ -3,12 +3,17 @@ package incident
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
 )
 
 func TestParseConfigDefaults(t *testing.T) {
@@ -25,6 +30,9 @@ func TestParseConfigDefaults(t *testing.T) {
 	if cfg.APIToken != "" {
 		t.Fatalf("expected empty API token by default")
 	}
+	if cfg.Retry != common.DefaultRetryPolicy() {
+		t.Fatalf("expected default retry policy, got %+v", cfg.Retry)
+	}
 }
 
 func TestParseConfigOverride(t *testing.T) {
@@ -148,6 +156,93 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestCreateDeduplication(t *testing.T) {
+	var sentKeys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/incidents" && r.Method == "POST":
+			var body struct {
+				Incident struct {
+					IncidentKey string `json:"incident_key"`
+				} `json:"incident"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			sentKeys = append(sentKeys, body.Incident.IncidentKey)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"Invalid Input Provided","code":2001,"errors":["Open incident with matching dedup key already exists on this service"]}}`))
+		case r.URL.Path == "/incidents" && r.Method == "GET":
+			query := r.URL.Query()
+			if query.Get("incident_key") != "retry-1" || query.Get("service_ids[]") != "PXXXXXX" {
+				json.NewEncoder(w).Encode(map[string]any{"incidents": []map[string]any{}})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"incidents": []map[string]any{
+					{
+						"id":           "PEXISTING",
+						"incident_key": "retry-1",
+						"title":        "Test incident",
+						"status":       "triggered",
+						"urgency":      "high",
+					},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg: Config{
+			Source:    "pagerduty",
+			APIToken:  "test-token",
+			APIURL:    server.URL,
+			ServiceID: "PXXXXXX",
+			FromEmail: "user@example.com",
+		},
+		client: &http.Client{},
+	}
+	ctx := context.Background()
+
+	t.Run("returns existing incident on dedup conflict", func(t *testing.T) {
+		inc, err := p.Create(ctx, schema.CreateIncidentInput{
+			Title:    "Test incident",
+			Metadata: map[string]any{"idempotency_key": "retry-1"},
+		})
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if inc.ID != "PEXISTING" {
+			t.Errorf("ID = %s, want PEXISTING", inc.ID)
+		}
+	})
+
+	t.Run("conflict without matching incident is an error", func(t *testing.T) {
+		_, err := p.Create(ctx, schema.CreateIncidentInput{Title: "Other incident"})
+		if !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Create() error = %v, want ErrValidation", err)
+		}
+	})
+
+	t.Run("derived key is stable", func(t *testing.T) {
+		sentKeys = nil
+		in := schema.CreateIncidentInput{Title: "Database down", Service: "db"}
+		p.Create(ctx, in)
+		p.Create(ctx, in)
+		p.Create(ctx, schema.CreateIncidentInput{Title: "Database down", Service: "api"})
+		if len(sentKeys) != 3 {
+			t.Fatalf("expected 3 create attempts, got %d", len(sentKeys))
+		}
+		if sentKeys[0] == "" || sentKeys[0] != sentKeys[1] {
+			t.Errorf("expected identical derived keys, got %q and %q", sentKeys[0], sentKeys[1])
+		}
+		if sentKeys[0] == sentKeys[2] {
+			t.Errorf("expected different keys for different services")
+		}
+	})
+}
+
 func TestGet(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/incidents/PINCIDENT1" && r.Method == "GET" {
@@ -210,8 +305,8 @@ func TestGet(t *testing.T) {
 
 	t.Run("get non-existent incident", func(t *testing.T) {
 		_, err := p.Get(ctx, "NOTFOUND")
-		if err != errNotFound {
-			t.Errorf("Get() error = %v, want errNotFound", err)
+		if !errors.Is(err, common.ErrNotFound) {
+			t.Errorf("Get() error = %v, want ErrNotFound", err)
 		}
 	})
 
@@ -521,6 +616,179 @@ func TestQueryWithScope(t *testing.T) {
 			t.Errorf("expected 1 incident, got %d", len(incidents))
 		}
 	})
+
+	t.Run("unmatched scope", func(t *testing.T) {
+		// The mock rejects unscoped incident queries, so widening would fail.
+		exact := &PagerDutyProvider{cfg: p.cfg, client: p.client}
+		exact.cfg.Lookup.Mode = common.MatchExact
+		for _, scope := range []schema.QueryScope{{Service: "production api"}, {Team: "Platform"}} {
+			incidents, err := exact.Query(ctx, schema.IncidentQuery{Scope: scope})
+			if err != nil {
+				t.Fatalf("Query(%+v) error = %v", scope, err)
+			}
+			if len(incidents) != 0 {
+				t.Errorf("Query(%+v) = %d incidents, want none", scope, len(incidents))
+			}
+		}
+	})
+}
+
+func TestQueryWithEnvironment(t *testing.T) {
+	var serviceIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/services":
+			json.NewEncoder(w).Encode(map[string]any{
+				"services": []map[string]any{
+					{"id": "PSVC1", "name": "payments-prod"},
+					{"id": "PSVC2", "name": "payments-staging"},
+					{"id": "PSVC3", "name": "search-prod"},
+				},
+			})
+		case "/incidents":
+			serviceIDs = r.URL.Query()["service_ids[]"]
+			json.NewEncoder(w).Encode(map[string]any{
+				"incidents": []map[string]any{
+					{"id": "PINC1", "service": map[string]any{"id": "PSVC1", "summary": "payments-prod"}},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg: Config{
+			Source:       "pagerduty",
+			APIToken:     "test-token",
+			APIURL:       server.URL,
+			Environments: common.Environments{Pattern: `-(prod|staging)$`},
+		},
+		client: &http.Client{},
+	}
+	if err := p.cfg.Environments.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	incidents, err := p.Query(ctx, schema.IncidentQuery{Scope: schema.QueryScope{Environment: "prod"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(serviceIDs) != 2 || serviceIDs[0] != "PSVC1" || serviceIDs[1] != "PSVC3" {
+		t.Errorf("service_ids[] = %v, want [PSVC1 PSVC3]", serviceIDs)
+	}
+	if len(incidents) != 1 || incidents[0].Metadata["environment"] != "prod" {
+		t.Errorf("expected one prod incident, got %+v", incidents)
+	}
+
+	serviceIDs = nil
+	incidents, err = p.Query(ctx, schema.IncidentQuery{
+		Scope:    schema.QueryScope{Environment: "staging"},
+		Metadata: map[string]any{},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(serviceIDs) != 1 || serviceIDs[0] != "PSVC2" {
+		t.Errorf("service_ids[] = %v, want [PSVC2]", serviceIDs)
+	}
+
+	serviceIDs = nil
+	incidents, err = p.Query(ctx, schema.IncidentQuery{Scope: schema.QueryScope{Environment: "dev"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if serviceIDs != nil || len(incidents) != 0 {
+		t.Errorf("expected no incident request for an empty environment, got %v / %v", serviceIDs, incidents)
+	}
+
+	// Metadata service IDs are intersected with the environment.
+	incidents, err = p.Query(ctx, schema.IncidentQuery{
+		Scope:    schema.QueryScope{Environment: "prod"},
+		Metadata: map[string]any{"service_id": "PSVC2"},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if serviceIDs != nil || len(incidents) != 0 {
+		t.Errorf("a staging service_id must not widen a prod query, got %v / %v", serviceIDs, incidents)
+	}
+	_, err = p.Query(ctx, schema.IncidentQuery{
+		Scope:    schema.QueryScope{Environment: "prod"},
+		Metadata: map[string]any{"service_id": "PSVC3"},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(serviceIDs) != 1 || serviceIDs[0] != "PSVC3" {
+		t.Errorf("service_ids[] = %v, want [PSVC3]", serviceIDs)
+	}
+}
+
+func TestQuerySplitsLargeServiceIDSets(t *testing.T) {
+	var services []map[string]any
+	for i := 0; i < 250; i++ {
+		services = append(services, map[string]any{"id": fmt.Sprintf("PSVC%03d", i), "name": fmt.Sprintf("svc%03d-prod", i)})
+	}
+	var chunks [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/services":
+			json.NewEncoder(w).Encode(map[string]any{"services": services})
+		case "/incidents":
+			ids := r.URL.Query()["service_ids[]"]
+			chunks = append(chunks, ids)
+			// Every chunk returns its own incident plus one shared incident.
+			json.NewEncoder(w).Encode(map[string]any{
+				"incidents": []map[string]any{
+					{"id": "PINC-" + ids[0], "title": strings.ToLower(ids[0]), "created_at": "2024-01-01T00:00:00Z"},
+					{"id": "PSHARED", "title": "shared", "created_at": "2024-01-02T00:00:00Z"},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg: Config{
+			Source:       "pagerduty",
+			APIToken:     "test-token",
+			APIURL:       server.URL,
+			Environments: common.Environments{Pattern: `-(prod)$`},
+		},
+		client: &http.Client{},
+	}
+	if err := p.cfg.Environments.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	incidents, err := p.Query(context.Background(), schema.IncidentQuery{
+		Scope:    schema.QueryScope{Environment: "prod"},
+		Limit:    2,
+		Metadata: map[string]any{"sort_by": "name:desc"},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 /incidents requests, got %d", len(chunks))
+	}
+	for i, want := range []int{100, 100, 50} {
+		if len(chunks[i]) != want {
+			t.Errorf("request %d sent %d service_ids[], want %d", i, len(chunks[i]), want)
+		}
+	}
+	var ids []string
+	for _, inc := range incidents {
+		ids = append(ids, inc.ID)
+	}
+	if want := []string{"PSHARED", "PINC-PSVC200"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Query() = %v, want %v", ids, want)
+	}
 }
 
 func TestQueryServiceIDFilter(t *testing.T) {
@@ -617,6 +885,130 @@ func TestQueryServiceIDFilter(t *testing.T) {
 	})
 }
 
+func TestQueryPagination(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/incidents" || r.Method != "GET" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests++
+		query := r.URL.Query()
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+
+		// 250 incidents in total
+		const total = 250
+		var incidents []map[string]any
+		for i := offset; i < offset+limit && i < total; i++ {
+			incidents = append(incidents, map[string]any{
+				"id":      fmt.Sprintf("INC%d", i),
+				"title":   fmt.Sprintf("Incident %d", i),
+				"status":  "triggered",
+				"urgency": "high",
+			})
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]any{
+			"incidents": incidents,
+			"offset":    offset,
+			"limit":     limit,
+			"more":      offset+len(incidents) < total,
+		})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{APIToken: "token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+
+	t.Run("fetches all pages without limit", func(t *testing.T) {
+		requests = 0
+		incidents, err := p.Query(context.Background(), schema.IncidentQuery{})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if len(incidents) != 250 {
+			t.Errorf("len(incidents) = %d, want 250", len(incidents))
+		}
+		if requests != 3 {
+			t.Errorf("requests = %d, want 3", requests)
+		}
+		if incidents[249].ID != "INC249" {
+			t.Errorf("last incident = %s, want INC249", incidents[249].ID)
+		}
+	})
+
+	t.Run("stops at limit", func(t *testing.T) {
+		requests = 0
+		incidents, err := p.Query(context.Background(), schema.IncidentQuery{Limit: 150})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if len(incidents) != 150 {
+			t.Errorf("len(incidents) = %d, want 150", len(incidents))
+		}
+		if requests != 2 {
+			t.Errorf("requests = %d, want 2", requests)
+		}
+	})
+
+	t.Run("honors context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := p.Query(ctx, schema.IncidentQuery{}); err == nil {
+			t.Fatalf("expected error for cancelled context")
+		}
+	})
+}
+
+func TestQueryStopsAtPaginationCeiling(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query := r.URL.Query()
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		if offset+limit > 10000 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		incidents := make([]map[string]any, limit)
+		for i := range incidents {
+			incidents[i] = map[string]any{"id": fmt.Sprintf("INC%d", offset+i)}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"incidents": incidents, "more": true})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{APIToken: "token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+
+	incidents, err := p.Query(context.Background(), schema.IncidentQuery{Metadata: map[string]any{"date_range": "all"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(incidents) != 10000 || requests != 100 {
+		t.Fatalf("got %d incidents in %d requests, want 10000 in 100", len(incidents), requests)
+	}
+	if incidents[0].Metadata["results_truncated"] != true {
+		t.Errorf("expected results_truncated, got %v", incidents[0].Metadata)
+	}
+
+	// A Limit reached before the ceiling is not a truncation.
+	incidents, err = p.Query(context.Background(), schema.IncidentQuery{Limit: 5})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if _, ok := incidents[0].Metadata["results_truncated"]; ok {
+		t.Errorf("unexpected results_truncated with a Limit")
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/incidents/PINCIDENT1" && r.Method == "PUT" {
@@ -681,12 +1073,192 @@ func TestUpdate(t *testing.T) {
 		_, err := p.Update(ctx, "NOTFOUND", schema.UpdateIncidentInput{
 			Title: &newTitle,
 		})
-		if err != errNotFound {
-			t.Errorf("Update() error = %v, want errNotFound", err)
+		if !errors.Is(err, common.ErrNotFound) {
+			t.Errorf("Update() error = %v, want ErrNotFound", err)
 		}
 	})
 }
 
+func TestUpdateMetadata(t *testing.T) {
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/users":
+			json.NewEncoder(w).Encode(map[string]any{
+				"users": []map[string]any{{"id": "PUSER1", "name": "Jane Doe", "email": "jane@example.com"}},
+			})
+		case r.URL.Path == "/escalation_policies":
+			json.NewEncoder(w).Encode(map[string]any{
+				"escalation_policies": []map[string]any{{"id": "PPOLICY", "name": "Database"}},
+			})
+		case r.URL.Path == "/priorities":
+			json.NewEncoder(w).Encode(map[string]any{
+				"priorities": []map[string]any{{"id": "PPRIO1", "name": "P1"}, {"id": "PPRIO2", "name": "P2"}},
+			})
+		case r.URL.Path == "/services":
+			json.NewEncoder(w).Encode(map[string]any{
+				"services": []map[string]any{{"id": "PSVC2", "name": "Payments"}, {"id": "PSVC3", "name": "Payments Batch"}},
+			})
+		case r.URL.Path == "/incidents/PINCIDENT1" && r.Method == "PUT":
+			sent = nil
+			json.NewDecoder(r.Body).Decode(&sent)
+			json.NewEncoder(w).Encode(map[string]any{
+				"incident": map[string]any{"id": "PINCIDENT1", "status": "acknowledged"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg: Config{
+			Source:    "pagerduty",
+			APIToken:  "test-token",
+			APIURL:    server.URL,
+			ServiceID: "PXXXXXX",
+			FromEmail: "user@example.com",
+		},
+		client: &http.Client{},
+	}
+	ctx := context.Background()
+
+	t.Run("resolves names to references", func(t *testing.T) {
+		_, err := p.Update(ctx, "PINCIDENT1", schema.UpdateIncidentInput{
+			Metadata: map[string]any{
+				"assignees":    []any{"jane@example.com"},
+				"assignee_ids": "PUSER9",
+				"priority":     "P2",
+				"service_id":   "PSVC9",
+			},
+		})
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		incident := sent["incident"].(map[string]any)
+		assignments := incident["assignments"].([]any)
+		if len(assignments) != 2 {
+			t.Fatalf("expected 2 assignments, got %v", assignments)
+		}
+		first := assignments[0].(map[string]any)["assignee"].(map[string]any)
+		second := assignments[1].(map[string]any)["assignee"].(map[string]any)
+		if first["id"] != "PUSER9" || second["id"] != "PUSER1" || second["type"] != "user_reference" {
+			t.Errorf("unexpected assignments %v", assignments)
+		}
+		if ref := incident["priority"].(map[string]any); ref["id"] != "PPRIO2" {
+			t.Errorf("priority = %v", ref)
+		}
+		if ref := incident["service"].(map[string]any); ref["id"] != "PSVC9" {
+			t.Errorf("service = %v", ref)
+		}
+	})
+
+	t.Run("escalation level and resolution", func(t *testing.T) {
+		status := "resolved"
+		_, err := p.Update(ctx, "PINCIDENT1", schema.UpdateIncidentInput{
+			Status: &status,
+			Metadata: map[string]any{
+				"escalation_policy": "Database",
+				"escalation_level":  float64(2),
+				"resolution":        "Rolled back the deploy",
+			},
+		})
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		incident := sent["incident"].(map[string]any)
+		if ref := incident["escalation_policy"].(map[string]any); ref["id"] != "PPOLICY" || ref["type"] != "escalation_policy_reference" {
+			t.Errorf("escalation_policy = %v", ref)
+		}
+		if incident["escalation_level"] != float64(2) {
+			t.Errorf("escalation_level = %v, want 2", incident["escalation_level"])
+		}
+		if incident["resolution"] != "Rolled back the deploy" {
+			t.Errorf("resolution = %v", incident["resolution"])
+		}
+	})
+
+	t.Run("invalid combinations", func(t *testing.T) {
+		service := "Pay"
+		tests := []schema.UpdateIncidentInput{
+			{Metadata: map[string]any{"resolution": "done"}},
+			{Metadata: map[string]any{"escalation_policy_id": "PPOLICY", "assignee_ids": "PUSER1"}},
+			{Metadata: map[string]any{"escalation_policy": "Database", "assignees": "jane@example.com"}},
+			{Metadata: map[string]any{"priority": "P9"}},
+			{Service: &service},
+		}
+		for _, in := range tests {
+			if _, err := p.Update(ctx, "PINCIDENT1", in); !errors.Is(err, common.ErrValidation) {
+				t.Errorf("Update(%v) error = %v, want ErrValidation", in.Metadata, err)
+			}
+		}
+	})
+}
+
+func TestConvertIncidentDetails(t *testing.T) {
+	raw := `{
+		"id": "PINC",
+		"incident_number": 1234,
+		"status": "resolved",
+		"urgency": "high",
+		"acknowledgements": [
+			{"at": "2025-11-21T10:05:00Z", "acknowledger": {"id": "PUSER1", "type": "user_reference", "summary": "Jane Doe", "html_url": "https://pd/users/PUSER1"}}
+		],
+		"escalation_policy": {"id": "PPOLICY", "type": "escalation_policy_reference", "summary": "Database", "html_url": "https://pd/ep/PPOLICY"},
+		"teams": [{"id": "PTEAM", "type": "team_reference", "summary": "Storage", "html_url": "https://pd/teams/PTEAM"}],
+		"resolve_reason": {"type": "merge_resolve_reason", "incident": {"id": "PPARENT", "html_url": "https://pd/incidents/PPARENT"}},
+		"conference_bridge": {"conference_number": "+1 555 0100,,123#", "conference_url": "https://meet.example.com/db"},
+		"first_trigger_log_entry": {"id": "RLOG1", "type": "trigger_log_entry_reference", "summary": "Triggered through the API"},
+		"alert_counts": {"all": 3, "triggered": 1, "resolved": 2},
+		"pending_actions": [{"type": "urgency_change", "at": "2025-11-21T18:00:00Z"}],
+		"last_status_change_by": {"id": "PUSER1", "type": "user_reference", "summary": "Jane Doe"}
+	}`
+	var pdInc pdIncident
+	if err := json.Unmarshal([]byte(raw), &pdInc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	md := convertPDIncident(pdInc, Config{Source: "pagerduty"}).Metadata
+
+	if md["incident_number"] != 1234 {
+		t.Errorf("incident_number = %v", md["incident_number"])
+	}
+	acks := md["acknowledgements"].([]map[string]string)
+	if len(acks) != 1 || acks[0]["name"] != "Jane Doe" || acks[0]["at"] != "2025-11-21T10:05:00Z" {
+		t.Errorf("acknowledgements = %v", acks)
+	}
+	if ep := md["escalation_policy"].(map[string]string); ep["id"] != "PPOLICY" || ep["name"] != "Database" {
+		t.Errorf("escalation_policy = %v", ep)
+	}
+	if teams := md["teams"].([]map[string]string); len(teams) != 1 || teams[0]["name"] != "Storage" {
+		t.Errorf("teams = %v", teams)
+	}
+	if rr := md["resolve_reason"].(map[string]string); rr["type"] != "merge_resolve_reason" || rr["incident_id"] != "PPARENT" {
+		t.Errorf("resolve_reason = %v", rr)
+	}
+	if cb := md["conference_bridge"].(map[string]string); cb["conference_url"] != "https://meet.example.com/db" {
+		t.Errorf("conference_bridge = %v", cb)
+	}
+	if le := md["first_trigger_log_entry"].(map[string]string); le["id"] != "RLOG1" {
+		t.Errorf("first_trigger_log_entry = %v", le)
+	}
+	if ac := md["alert_counts"].(map[string]int); ac["all"] != 3 || ac["resolved"] != 2 {
+		t.Errorf("alert_counts = %v", ac)
+	}
+	if pa := md["pending_actions"].([]map[string]string); len(pa) != 1 || pa[0]["type"] != "urgency_change" {
+		t.Errorf("pending_actions = %v", pa)
+	}
+	if by := md["last_status_change_by"].(map[string]string); by["type"] != "user_reference" || by["name"] != "Jane Doe" {
+		t.Errorf("last_status_change_by = %v", by)
+	}
+
+	sparse := convertPDIncident(pdIncident{ID: "PSPARSE"}, Config{Source: "pagerduty"}).Metadata
+	for _, key := range []string{"incident_number", "acknowledgements", "escalation_policy", "teams", "conference_bridge", "alert_counts"} {
+		if _, ok := sparse[key]; ok {
+			t.Errorf("expected %s to be omitted for a sparse incident", key)
+		}
+	}
+}
+
 func TestMappingFunctions(t *testing.T) {
 	t.Run("mapSeverityToUrgency", func(t *testing.T) {
 		tests := []struct {
@@ -726,3 +1298,60 @@ func TestMappingFunctions(t *testing.T) {
 		}
 	})
 }
+
+func TestMerge(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/incidents/PTARGET/merge" || r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]any{
+			"incident": map[string]any{
+				"id":           "PTARGET",
+				"title":        "Checkout down",
+				"status":       "triggered",
+				"urgency":      "high",
+				"service":      map[string]any{"id": "PSVC1", "summary": "Checkout"},
+				"created_at":   "2025-01-01T10:00:00Z",
+				"updated_at":   "2025-01-01T10:20:00Z",
+				"alert_counts": map[string]any{"all": 3, "triggered": 3, "resolved": 0},
+			},
+		})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{cfg: Config{APIToken: "token", APIURL: server.URL, FromEmail: "oncall@example.com"}, client: &http.Client{}}
+	ctx := context.Background()
+
+	inc, err := p.Merge(ctx, "PTARGET", []string{"PSRC1", "PSRC2"})
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if inc.ID != "PTARGET" || inc.Title != "Checkout down" {
+		t.Errorf("Merge() = %+v, want the target incident", inc)
+	}
+	wantBody := `{"source_incidents":[{"id":"PSRC1","type":"incident_reference"},{"id":"PSRC2","type":"incident_reference"}]}`
+	if got, _ := json.Marshal(body); string(got) != wantBody {
+		t.Errorf("merge body = %s, want %s", got, wantBody)
+	}
+
+	for _, tc := range []struct {
+		target  string
+		sources []string
+	}{
+		{"", []string{"PSRC1"}},
+		{"PTARGET", nil},
+		{"PTARGET", []string{"PTARGET"}},
+		{"PTARGET", []string{" "}},
+	} {
+		if _, err := p.Merge(ctx, tc.target, tc.sources); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("Merge(%q, %v) error = %v, want validation error", tc.target, tc.sources, err)
+		}
+	}
+
+	if _, err := p.Merge(ctx, "MISSING", []string{"PSRC1"}); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package incident
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Search defaults. PagerDuty has no full-text search on incidents, so Query
+// fetches candidates and matches them locally; these bound that work.
+const (
+	defaultSearchMaxResults = 100
+	defaultSearchMaxScan    = 1000
+)
+
+// searchTerms is a parsed free-text query. Every term must match.
+type searchTerms []string
+
+// parseSearch splits a query into lower-cased terms. Double-quoted text is
+// kept as one phrase; everything else is split on whitespace.
+func parseSearch(query string) searchTerms {
+	var terms searchTerms
+	var b strings.Builder
+	inPhrase := false
+	flush := func() {
+		if t := strings.TrimSpace(b.String()); t != "" {
+			terms = append(terms, strings.ToLower(t))
+		}
+		b.Reset()
+	}
+	for _, r := range query {
+		switch {
+		case r == '"':
+			flush()
+			inPhrase = !inPhrase
+		case unicode.IsSpace(r) && !inPhrase:
+			flush()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	flush()
+	return terms
+}
+
+// match reports whether every term occurs in the incident's title,
+// description, service name or incident key, or equals its incident number
+// (with or without a leading #).
+func (terms searchTerms) match(inc pdIncident) bool {
+	text := strings.ToLower(strings.Join([]string{
+		inc.Title,
+		inc.Body.Details,
+		inc.Service.Summary,
+		inc.IncidentKey,
+	}, "\n"))
+	number := ""
+	if inc.IncidentNumber > 0 {
+		number = strconv.Itoa(inc.IncidentNumber)
+	}
+	for _, term := range terms {
+		if number != "" && strings.TrimPrefix(term, "#") == number {
+			continue
+		}
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
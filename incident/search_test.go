@@ -0,0 +1,111 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestParseSearch(t *testing.T) {
+	got := parseSearch(`  Database "connection pool"  #42 `)
+	want := searchTerms{"database", "connection pool", "#42"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSearch() = %q, want %q", got, want)
+	}
+}
+
+func TestSearchTermsMatch(t *testing.T) {
+	inc := pdIncident{
+		Title:          "Connection pool exhausted",
+		IncidentKey:    "db-primary-pool",
+		IncidentNumber: 1042,
+	}
+	inc.Service.Summary = "Payments Database"
+	inc.Body.Details = "Too many clients"
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"connection pool", true},
+		{`"connection pool"`, true},
+		{`"pool connection"`, false},
+		{"payments clients", true},
+		{"db-primary", true},
+		{"#1042", true},
+		{"1042 pool", true},
+		{"104", false},
+		{"payments redis", false},
+	}
+	for _, tt := range tests {
+		if got := parseSearch(tt.query).match(inc); got != tt.want {
+			t.Errorf("match(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueryFreeText(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var incidents []map[string]any
+		for i := offset; i < offset+limit && i < 250; i++ {
+			title := "Disk usage high"
+			if i%10 == 0 {
+				title = "Database connection pool exhausted"
+			}
+			incidents = append(incidents, map[string]any{"id": "P" + strconv.Itoa(i), "title": title})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"incidents": incidents, "more": offset+limit < 250})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{Source: "pagerduty", APIToken: "test-token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+	ctx := context.Background()
+
+	t.Run("matches across pages", func(t *testing.T) {
+		incidents, err := p.Query(ctx, schema.IncidentQuery{Query: "connection pool"})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if len(incidents) != 25 {
+			t.Errorf("expected 25 matches, got %d", len(incidents))
+		}
+	})
+
+	t.Run("result cap", func(t *testing.T) {
+		p.cfg.SearchMaxResults = 5
+		defer func() { p.cfg.SearchMaxResults = 0 }()
+		incidents, err := p.Query(ctx, schema.IncidentQuery{Query: "disk"})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if len(incidents) != 5 {
+			t.Errorf("expected 5 results, got %d", len(incidents))
+		}
+	})
+
+	t.Run("scan cap", func(t *testing.T) {
+		p.cfg.SearchMaxScan = 50
+		defer func() { p.cfg.SearchMaxScan = 0 }()
+		requests = 0
+		incidents, err := p.Query(ctx, schema.IncidentQuery{Query: "database"})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if requests != 1 || len(incidents) != 5 {
+			t.Errorf("expected 1 request and 5 matches, got %d requests and %d matches", requests, len(incidents))
+		}
+	})
+}
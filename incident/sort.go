@@ -0,0 +1,66 @@
+package incident
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+// sortFields are the fields accepted in Metadata["sort_by"] for incidents.
+var sortFields = []string{"created_at", "urgency", "incident_number", "name"}
+
+// serverSortFields are the sort fields PagerDuty's GET /incidents supports.
+var serverSortFields = []string{"created_at", "urgency", "incident_number"}
+
+// serverSide reports whether PagerDuty can apply the sort itself.
+func serverSide(s common.Sort) bool {
+	return slices.Contains(serverSortFields, s.Field)
+}
+
+// chronological reports whether the sort follows creation order, in which
+// case time windows are walked in the same direction so Limit keeps the
+// right end of the range.
+func chronological(s common.Sort) bool {
+	return s.Field == "created_at" || s.Field == "incident_number"
+}
+
+// sortIncidents orders merged results. PagerDuty sorts each request, but
+// results from several time windows, and sorts it cannot apply (name), are
+// ordered here. The sort is stable so ties keep PagerDuty's order.
+func sortIncidents(incidents []schema.Incident, s common.Sort) {
+	compare := func(a, b schema.Incident) int {
+		switch s.Field {
+		case "created_at":
+			return a.CreatedAt.Compare(b.CreatedAt)
+		case "urgency":
+			return cmp.Compare(urgencyRank(a), urgencyRank(b))
+		case "incident_number":
+			return cmp.Compare(incidentNumber(a), incidentNumber(b))
+		case "name":
+			return cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		}
+		return 0
+	}
+	slices.SortStableFunc(incidents, func(a, b schema.Incident) int {
+		if s.Desc {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+}
+
+// urgencyRank orders low before high, matching PagerDuty's urgency:asc.
+func urgencyRank(inc schema.Incident) int {
+	if u, _ := inc.Metadata["urgency"].(string); u == "high" {
+		return 1
+	}
+	return 0
+}
+
+func incidentNumber(inc schema.Incident) int {
+	n, _ := inc.Metadata["incident_number"].(int)
+	return n
+}
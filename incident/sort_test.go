@@ -0,0 +1,122 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestQuerySort(t *testing.T) {
+	var requests []map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		requests = append(requests, query)
+		// Each window returns one incident created at its start.
+		since := query.Get("since")
+		if since == "" {
+			since = "2025-01-01T00:00:00Z"
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"incidents": []map[string]any{
+				{"id": "P" + since[:10], "title": "incident " + since[:7], "created_at": since},
+				{"id": "PZ" + since[:10], "title": "Alpha " + since[:7], "created_at": since},
+			},
+		})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{Source: "pagerduty", APIToken: "test-token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+	ctx := context.Background()
+
+	t.Run("newest first across windows", func(t *testing.T) {
+		requests = nil
+		incidents, err := p.Query(ctx, schema.IncidentQuery{
+			Limit: 2,
+			Metadata: map[string]any{
+				"since":   "2024-01-01T00:00:00Z",
+				"until":   "2025-01-01T00:00:00Z",
+				"sort_by": "created_at:desc",
+			},
+		})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if got := requests[0]["sort_by"]; len(got) != 1 || got[0] != "created_at:desc" {
+			t.Errorf("sort_by = %v, want created_at:desc", got)
+		}
+		if len(requests) != 1 || requests[0]["since"][0][:10] != "2024-12-26" {
+			t.Errorf("expected only the newest window to be queried, got %v", requests)
+		}
+		if len(incidents) != 2 {
+			t.Fatalf("expected 2 incidents, got %d", len(incidents))
+		}
+	})
+
+	t.Run("name is sorted client-side", func(t *testing.T) {
+		requests = nil
+		incidents, err := p.Query(ctx, schema.IncidentQuery{Metadata: map[string]any{"sort_by": "name"}})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if _, ok := requests[0]["sort_by"]; ok {
+			t.Errorf("name sort must not be sent to PagerDuty")
+		}
+		if incidents[0].Title != "Alpha 2025-01" {
+			t.Errorf("expected Alpha first, got %q", incidents[0].Title)
+		}
+	})
+
+	t.Run("unsupported field", func(t *testing.T) {
+		if _, err := p.Query(ctx, schema.IncidentQuery{Metadata: map[string]any{"sort_by": "title"}}); err == nil {
+			t.Error("expected error for unsupported sort field")
+		}
+	})
+}
+
+func TestQuerySortByNameBeforeLimit(t *testing.T) {
+	titles := []string{"zeta", "yak", "xray", "alpha", "beta"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		end := min(offset+limit, len(titles))
+		var incidents []map[string]any
+		for i := offset; i < end; i++ {
+			incidents = append(incidents, map[string]any{"id": fmt.Sprintf("P%d", i), "title": titles[i]})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"incidents": incidents, "more": end < len(titles)})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{Source: "pagerduty", APIToken: "test-token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+	ctx := context.Background()
+
+	incidents, err := p.Query(ctx, schema.IncidentQuery{Limit: 2, Metadata: map[string]any{"sort_by": "name"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(incidents) != 2 || incidents[0].Title != "alpha" || incidents[1].Title != "beta" {
+		t.Errorf("got %+v, want alpha and beta", incidents)
+	}
+
+	// The scan cap bounds how many candidates are sorted.
+	p.cfg.SearchMaxScan = 3
+	incidents, err = p.Query(ctx, schema.IncidentQuery{Limit: 2, Metadata: map[string]any{"sort_by": "name"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(incidents) != 2 || incidents[0].Title != "xray" || incidents[1].Title != "yak" {
+		t.Errorf("got %+v, want xray and yak", incidents)
+	}
+}
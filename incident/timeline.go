@@ -0,0 +1,466 @@
+package incident
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+// maxTimelinePages bounds GetTimeline so a misbehaving API cannot page forever.
+const maxTimelinePages = 100
+
+// Timeline kinds. PagerDuty log entry types are mapped onto this taxonomy so
+// Core never sees vendor vocabulary; the original type stays in
+// Metadata["type"].
+const (
+	kindCreated        = "created"
+	kindAcknowledged   = "acknowledged"
+	kindEscalated      = "escalated"
+	kindNotified       = "notified"
+	kindAssigned       = "assigned"
+	kindNote           = "note"
+	kindResolved       = "resolved"
+	kindStatusUpdate   = "status_update"
+	kindPriorityChange = "priority_change"
+	kindMerged         = "merged"
+	kindOther          = "other"
+)
+
+// timelineKinds lists the kinds accepted by GetTimelineByKind.
+var timelineKinds = []string{
+	kindCreated, kindAcknowledged, kindEscalated, kindNotified, kindAssigned, kindNote,
+	kindResolved, kindStatusUpdate, kindPriorityChange, kindMerged, kindOther,
+}
+
+// logEntryKinds maps PagerDuty log entry types to timeline kinds. Types not
+// listed become kindOther.
+var logEntryKinds = map[string]string{
+	"trigger_log_entry":                 kindCreated,
+	"acknowledge_log_entry":             kindAcknowledged,
+	"escalate_log_entry":                kindEscalated,
+	"repeat_escalation_path_log_entry":  kindEscalated,
+	"exhaust_escalation_path_log_entry": kindEscalated,
+	"notify_log_entry":                  kindNotified,
+	"assign_log_entry":                  kindAssigned,
+	"delegate_log_entry":                kindAssigned,
+	"annotate_log_entry":                kindNote,
+	"resolve_log_entry":                 kindResolved,
+	"status_update_log_entry":           kindStatusUpdate,
+	"priority_change_log_entry":         kindPriorityChange,
+	"urgency_change_log_entry":          kindPriorityChange,
+	"merge_log_entry":                   kindMerged,
+}
+
+// timelineKind returns the timeline kind of a PagerDuty log entry type.
+func timelineKind(logEntryType string) string {
+	if kind, ok := logEntryKinds[logEntryType]; ok {
+		return kind
+	}
+	return kindOther
+}
+
+// noteMatchWindow is how far apart a note and its annotate log entry may be
+// timestamped and still be treated as the same event.
+const noteMatchWindow = 5 * time.Second
+
+// GetTimeline returns the timeline for an incident from PagerDuty, oldest
+// first: every log entry, following PagerDuty's offset/more pagination,
+// merged with the incident's notes. Annotate log entries only carry a
+// truncated summary of the note, so they are replaced by the note itself.
+func (p *PagerDutyProvider) GetTimeline(ctx context.Context, id string) ([]schema.TimelineEntry, error) {
+	logEntries, err := p.listLogEntries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := p.listNotes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]schema.TimelineEntry, 0, len(logEntries)+len(notes))
+	matched := make([]bool, len(notes))
+	for _, le := range logEntries {
+		entry := convertPDLogEntry(le, id)
+		if le.Type == "annotate_log_entry" {
+			if i := matchNote(entry, notes, matched); i >= 0 {
+				matched[i] = true
+				notes[i].logEntryID = le.ID
+				continue
+			}
+		}
+		entries = append(entries, entry)
+	}
+	for _, note := range notes {
+		entries = append(entries, convertPDNote(note, id))
+	}
+
+	slices.SortStableFunc(entries, func(a, b schema.TimelineEntry) int {
+		return a.At.Compare(b.At)
+	})
+	return entries, nil
+}
+
+// GetTimelineByKind returns the timeline entries whose Kind is one of kinds.
+// No kinds means the whole timeline; unknown kinds are validation errors.
+func (p *PagerDutyProvider) GetTimelineByKind(ctx context.Context, id string, kinds []string) ([]schema.TimelineEntry, error) {
+	for _, kind := range kinds {
+		if !slices.Contains(timelineKinds, kind) {
+			return nil, common.Invalid(fmt.Errorf("timeline kind %q is not supported, must be one of %s", kind, strings.Join(timelineKinds, ", ")))
+		}
+	}
+	entries, err := p.GetTimeline(ctx, id)
+	if err != nil || len(kinds) == 0 {
+		return entries, err
+	}
+	out := entries[:0]
+	for _, entry := range entries {
+		if slices.Contains(kinds, entry.Kind) {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
+
+// listLogEntries fetches every log entry of an incident, with channel details.
+func (p *PagerDutyProvider) listLogEntries(ctx context.Context, id string) ([]pdLogEntry, error) {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(maxPageSize))
+	params.Add("include[]", "channels")
+
+	var out []pdLogEntry
+	for page, offset := 0, 0; page < maxTimelinePages; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		params.Set("offset", strconv.Itoa(offset))
+		var result struct {
+			LogEntries []pdLogEntry `json:"log_entries"`
+			More       bool         `json:"more"`
+		}
+		if err := p.api().Get(ctx, "/incidents/"+id+"/log_entries", params, &result); err != nil {
+			return nil, err
+		}
+		out = append(out, result.LogEntries...)
+		offset += len(result.LogEntries)
+		if !result.More || len(result.LogEntries) == 0 {
+			break
+		}
+	}
+	return out, nil
+}
+
+// listNotes fetches the notes of an incident.
+func (p *PagerDutyProvider) listNotes(ctx context.Context, id string) ([]pdNote, error) {
+	var result struct {
+		Notes []pdNote `json:"notes"`
+	}
+	if err := p.api().Get(ctx, "/incidents/"+id+"/notes", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Notes, nil
+}
+
+// matchNote returns the index of the unmatched note an annotate log entry
+// was written for, or -1. The note must be by the same author, created within
+// noteMatchWindow, and start with the entry's (possibly truncated) text.
+func matchNote(entry schema.TimelineEntry, notes []pdNote, matched []bool) int {
+	text := strings.TrimSpace(entry.Body)
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "..."), "…")
+	author, _ := entry.Actor["id"].(string)
+	for i, note := range notes {
+		if matched[i] {
+			continue
+		}
+		if author != "" && note.User != nil && note.User.ID != author {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, note.CreatedAt)
+		if err != nil || at.Sub(entry.At).Abs() > noteMatchWindow {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(note.Content), text) {
+			return i
+		}
+	}
+	return -1
+}
+
+// AppendTimeline adds an entry to an incident in PagerDuty. Kind
+// "status_update" posts a status update that notifies the incident's
+// subscribers; Metadata["subject"] and Metadata["html_message"] together send
+// a custom email instead of the default one. Any other kind adds a private
+// note.
+func (p *PagerDutyProvider) AppendTimeline(ctx context.Context, id string, entry schema.TimelineAppendInput) error {
+	if strings.EqualFold(strings.TrimSpace(entry.Kind), kindStatusUpdate) {
+		return p.addStatusUpdate(ctx, id, entry)
+	}
+	return p.addNote(ctx, id, entry)
+}
+
+func (p *PagerDutyProvider) addNote(ctx context.Context, id string, entry schema.TimelineAppendInput) error {
+	payload := map[string]any{
+		"note": map[string]any{
+			"content": entry.Body,
+		},
+	}
+
+	if err := p.api().Post(ctx, "/incidents/"+id+"/notes", payload, nil); err != nil {
+		return wrapNotFound(id, err)
+	}
+
+	return nil
+}
+
+func (p *PagerDutyProvider) addStatusUpdate(ctx context.Context, id string, entry schema.TimelineAppendInput) error {
+	if strings.TrimSpace(entry.Body) == "" {
+		return common.Invalid(errors.New("status update body is required"))
+	}
+	payload := map[string]any{"message": entry.Body}
+	subject := metadataString(entry.Metadata, "subject")
+	html := metadataString(entry.Metadata, "html_message")
+	if (subject == "") != (html == "") {
+		// A custom email needs both; PagerDuty rejects one without the other.
+		return common.Invalid(errors.New("status update subject and html_message must be set together"))
+	}
+	if subject != "" {
+		payload["subject"] = subject
+		payload["html_message"] = html
+	}
+
+	if err := p.api().Post(ctx, "/incidents/"+id+"/status_updates", payload, nil); err != nil {
+		return wrapNotFound(id, err)
+	}
+
+	return nil
+}
+
+// Subscriber is an entity notified of an incident's status updates.
+type Subscriber struct {
+	ID   string `json:"id"`
+	Type string `json:"type"` // "user" or "team"
+	// Indirect is set when the subscription comes through a business service
+	// or team rather than the incident itself.
+	Indirect      bool                `json:"indirect"`
+	SubscribedVia []map[string]string `json:"subscribedVia,omitempty"`
+}
+
+// Subscribers lists the users and teams subscribed to an incident's status
+// updates.
+func (p *PagerDutyProvider) Subscribers(ctx context.Context, id string) ([]Subscriber, error) {
+	var result struct {
+		Subscribers []struct {
+			SubscriberID            string `json:"subscriber_id"`
+			SubscriberType          string `json:"subscriber_type"`
+			HasIndirectSubscription bool   `json:"has_indirect_subscription"`
+			SubscribedVia           []struct {
+				Name string `json:"name"`
+				Type string `json:"type"`
+			} `json:"subscribed_via"`
+		} `json:"subscribers"`
+	}
+	if err := p.api().Get(ctx, "/incidents/"+id+"/status_updates/subscribers", nil, &result); err != nil {
+		return nil, wrapNotFound(id, err)
+	}
+
+	out := make([]Subscriber, len(result.Subscribers))
+	for i, sub := range result.Subscribers {
+		out[i] = Subscriber{ID: sub.SubscriberID, Type: sub.SubscriberType, Indirect: sub.HasIndirectSubscription}
+		for _, via := range sub.SubscribedVia {
+			out[i].SubscribedVia = append(out[i].SubscribedVia, map[string]string{"name": via.Name, "type": via.Type})
+		}
+	}
+	return out, nil
+}
+
+// pdLogEntry represents a PagerDuty log entry, including the channel details
+// returned with include[]=channels.
+type pdLogEntry struct {
+	ID               string          `json:"id"`
+	Type             string          `json:"type"`
+	Summary          string          `json:"summary"`
+	CreatedAt        string          `json:"created_at"`
+	HTMLURL          string          `json:"html_url"`
+	Agent            *pdReference    `json:"agent"`
+	Channel          *pdChannel      `json:"channel"`
+	User             *pdReference    `json:"user"`
+	Assignees        []pdReference   `json:"assignees"`
+	EscalationPolicy *pdReference    `json:"escalation_policy"`
+	EscalationLevel  int             `json:"escalation_level"`
+	Notification     *pdNotification `json:"notification"`
+	SourceIncidents  []pdReference   `json:"source_incidents"`
+}
+
+// pdChannel describes how a log entry came about: an email, SMS, phone call,
+// API event, web trigger, note and so on. Which fields are set depends on Type.
+type pdChannel struct {
+	Type        string `json:"type"`
+	Summary     string `json:"summary"`
+	Subject     string `json:"subject"`
+	Body        string `json:"body"`
+	Content     string `json:"content"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Description string `json:"description"`
+	Client      string `json:"client"`
+	ClientURL   string `json:"client_url"`
+	IncidentKey string `json:"incident_key"`
+	Details     any    `json:"details"`
+}
+
+// metadata returns the non-empty channel fields.
+func (c pdChannel) metadata() map[string]any {
+	md := map[string]any{"type": c.Type}
+	for key, val := range map[string]string{
+		"summary":      c.Summary,
+		"subject":      c.Subject,
+		"body":         c.Body,
+		"content":      c.Content,
+		"from":         c.From,
+		"to":           c.To,
+		"description":  c.Description,
+		"client":       c.Client,
+		"client_url":   c.ClientURL,
+		"incident_key": c.IncidentKey,
+	} {
+		if val != "" {
+			md[key] = val
+		}
+	}
+	if c.Details != nil {
+		md["details"] = c.Details
+	}
+	return md
+}
+
+// note returns the note text carried by an annotate log entry's channel.
+func (c pdChannel) note() string {
+	return defaultString(c.Content, defaultString(c.Body, c.Summary))
+}
+
+// pdNotification is the notification sent for a notify log entry.
+type pdNotification struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Address string `json:"address"`
+}
+
+// actor returns the reference in the shape used for TimelineEntry.Actor.
+func (r pdReference) actor() map[string]any {
+	actor := map[string]any{
+		"id":   r.ID,
+		"type": r.Type,
+		"name": r.Summary,
+	}
+	if r.HTMLURL != "" {
+		actor["html_url"] = r.HTMLURL
+	}
+	return actor
+}
+
+// pdNote represents a note on a PagerDuty incident.
+type pdNote struct {
+	ID        string       `json:"id"`
+	Content   string       `json:"content"`
+	CreatedAt string       `json:"created_at"`
+	User      *pdReference `json:"user"`
+
+	logEntryID string // annotate log entry merged into the note
+}
+
+func convertPDNote(note pdNote, incidentID string) schema.TimelineEntry {
+	entry := schema.TimelineEntry{
+		ID:         note.ID,
+		IncidentID: incidentID,
+		Kind:       kindNote,
+		Body:       note.Content,
+		Metadata: map[string]any{
+			"type": "note",
+		},
+	}
+
+	if at, err := time.Parse(time.RFC3339, note.CreatedAt); err == nil {
+		entry.At = at
+	}
+	if note.logEntryID != "" {
+		entry.Metadata["log_entry_id"] = note.logEntryID
+	}
+	if note.User != nil {
+		entry.Actor = note.User.actor()
+	}
+
+	return entry
+}
+
+func convertPDLogEntry(le pdLogEntry, incidentID string) schema.TimelineEntry {
+	entry := schema.TimelineEntry{
+		ID:         le.ID,
+		IncidentID: incidentID,
+		Kind:       timelineKind(le.Type),
+		Body:       le.Summary,
+		Metadata: map[string]any{
+			"type": le.Type,
+		},
+	}
+
+	if at, err := time.Parse(time.RFC3339, le.CreatedAt); err == nil {
+		entry.At = at
+	}
+	if le.HTMLURL != "" {
+		entry.Metadata["html_url"] = le.HTMLURL
+	}
+
+	if le.Agent != nil {
+		entry.Actor = le.Agent.actor()
+	}
+
+	if ch := le.Channel; ch != nil {
+		entry.Metadata["channel"] = ch.metadata()
+		if le.Type == "annotate_log_entry" {
+			if note := ch.note(); note != "" {
+				entry.Metadata["note"] = note
+				entry.Body = note
+			}
+		}
+	}
+
+	if le.User != nil {
+		entry.Metadata["user"] = le.User.metadata()
+	}
+	if len(le.Assignees) > 0 {
+		assignees := make([]map[string]string, len(le.Assignees))
+		for i, a := range le.Assignees {
+			assignees[i] = a.metadata()
+		}
+		entry.Metadata["assignees"] = assignees
+	}
+	if le.EscalationPolicy != nil {
+		entry.Metadata["escalation_policy"] = le.EscalationPolicy.metadata()
+	}
+	if le.EscalationLevel > 0 {
+		entry.Metadata["escalation_level"] = le.EscalationLevel
+	}
+	if len(le.SourceIncidents) > 0 {
+		sources := make([]map[string]string, len(le.SourceIncidents))
+		for i, src := range le.SourceIncidents {
+			sources[i] = src.metadata()
+		}
+		entry.Metadata["merged_incidents"] = sources
+	}
+	if n := le.Notification; n != nil {
+		entry.Metadata["notification"] = map[string]string{
+			"type":    n.Type,
+			"status":  n.Status,
+			"address": n.Address,
+		}
+	}
+
+	return entry
+}
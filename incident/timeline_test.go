@@ -0,0 +1,371 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+func TestGetTimelinePaginates(t *testing.T) {
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/incidents/PINC1/notes" {
+			json.NewEncoder(w).Encode(map[string]any{"notes": []any{}})
+			return
+		}
+		if r.URL.Path != "/incidents/PINC1/log_entries" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if got := r.URL.Query()["include[]"]; !reflect.DeepEqual(got, []string{"channels"}) {
+			t.Errorf("include[] = %v, want [channels]", got)
+		}
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+		if offset == "0" {
+			json.NewEncoder(w).Encode(map[string]any{
+				"log_entries": []map[string]any{
+					{"id": "L1", "type": "trigger_log_entry", "summary": "Triggered", "created_at": "2025-01-01T10:00:00Z"},
+				},
+				"more": true,
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"log_entries": []map[string]any{
+				{"id": "L2", "type": "resolve_log_entry", "summary": "Resolved", "created_at": "2025-01-01T11:00:00Z"},
+			},
+			"more": false,
+		})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{cfg: Config{APIToken: "token", APIURL: server.URL}, client: &http.Client{}}
+	entries, err := p.GetTimeline(context.Background(), "PINC1")
+	if err != nil {
+		t.Fatalf("GetTimeline() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != "L1" || entries[1].ID != "L2" {
+		t.Fatalf("GetTimeline() = %+v, want L1 and L2", entries)
+	}
+	if !reflect.DeepEqual(offsets, []string{"0", "1"}) {
+		t.Errorf("offsets = %v, want [0 1]", offsets)
+	}
+}
+
+func TestGetTimelineMergesNotes(t *testing.T) {
+	longNote := "Rolled back deploy 4812 after checkout latency rose above the SLO; monitoring error rates before closing"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/incidents/PINC1/log_entries":
+			json.NewEncoder(w).Encode(map[string]any{
+				"log_entries": []map[string]any{
+					{"id": "L1", "type": "trigger_log_entry", "summary": "Triggered", "created_at": "2025-01-01T10:00:00Z"},
+					{
+						"id": "L2", "type": "annotate_log_entry", "summary": "Note added", "created_at": "2025-01-01T10:10:01Z",
+						"agent":   map[string]any{"id": "PUSER1", "type": "user_reference", "summary": "Jane Doe"},
+						"channel": map[string]any{"type": "note", "summary": longNote[:40] + "..."},
+					},
+					{"id": "L3", "type": "resolve_log_entry", "summary": "Resolved", "created_at": "2025-01-01T10:30:00Z"},
+				},
+			})
+		case "/incidents/PINC1/notes":
+			json.NewEncoder(w).Encode(map[string]any{
+				"notes": []map[string]any{
+					{
+						"id": "N1", "content": longNote, "created_at": "2025-01-01T10:10:00Z",
+						"user": map[string]any{"id": "PUSER1", "type": "user_reference", "summary": "Jane Doe"},
+					},
+					{
+						"id": "N2", "content": "Customer comms sent", "created_at": "2025-01-01T10:20:00Z",
+						"user": map[string]any{"id": "PUSER2", "type": "user_reference", "summary": "John Roe"},
+					},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{cfg: Config{APIToken: "token", APIURL: server.URL}, client: &http.Client{}}
+	entries, err := p.GetTimeline(context.Background(), "PINC1")
+	if err != nil {
+		t.Fatalf("GetTimeline() error = %v", err)
+	}
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	if want := []string{"L1", "N1", "N2", "L3"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("timeline IDs = %v, want %v", ids, want)
+	}
+	var kinds []string
+	for _, e := range entries {
+		kinds = append(kinds, e.Kind)
+	}
+	if want := []string{"created", "note", "note", "resolved"}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("timeline kinds = %v, want %v", kinds, want)
+	}
+	note := entries[1]
+	if note.Kind != "note" || note.Body != longNote {
+		t.Errorf("note Kind = %q, Body = %q", note.Kind, note.Body)
+	}
+	if note.Actor["name"] != "Jane Doe" || note.Actor["id"] != "PUSER1" {
+		t.Errorf("note Actor = %v", note.Actor)
+	}
+	if note.Metadata["log_entry_id"] != "L2" {
+		t.Errorf("log_entry_id = %v, want L2", note.Metadata["log_entry_id"])
+	}
+}
+
+func TestGetTimelineByKind(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/incidents/PINC1/log_entries":
+			json.NewEncoder(w).Encode(map[string]any{
+				"log_entries": []map[string]any{
+					{"id": "L1", "type": "trigger_log_entry", "created_at": "2025-01-01T10:00:00Z"},
+					{"id": "L2", "type": "acknowledge_log_entry", "created_at": "2025-01-01T10:05:00Z"},
+					{"id": "L3", "type": "snooze_log_entry", "created_at": "2025-01-01T10:06:00Z"},
+					{"id": "L4", "type": "resolve_log_entry", "created_at": "2025-01-01T10:30:00Z"},
+				},
+			})
+		case "/incidents/PINC1/notes":
+			json.NewEncoder(w).Encode(map[string]any{"notes": []any{}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{cfg: Config{APIToken: "token", APIURL: server.URL}, client: &http.Client{}}
+	ctx := context.Background()
+
+	entries, err := p.GetTimelineByKind(ctx, "PINC1", []string{"acknowledged", "resolved"})
+	if err != nil {
+		t.Fatalf("GetTimelineByKind() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != "L2" || entries[1].ID != "L4" {
+		t.Errorf("GetTimelineByKind() = %+v, want L2 and L4", entries)
+	}
+
+	entries, err = p.GetTimelineByKind(ctx, "PINC1", []string{"other"})
+	if err != nil || len(entries) != 1 || entries[0].Metadata["type"] != "snooze_log_entry" {
+		t.Errorf("other entries = %+v, %v; want the snooze entry with its original type", entries, err)
+	}
+
+	if _, err := p.GetTimelineByKind(ctx, "PINC1", []string{"trigger_log_entry"}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("expected validation error for an unknown kind, got %v", err)
+	}
+}
+
+func TestAppendTimeline(t *testing.T) {
+	var paths []string
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("From") != "oncall@example.com" {
+			t.Errorf("From = %q", r.Header.Get("From"))
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{cfg: Config{APIToken: "token", APIURL: server.URL, FromEmail: "oncall@example.com"}, client: &http.Client{}}
+	ctx := context.Background()
+
+	if err := p.AppendTimeline(ctx, "PINC1", schema.TimelineAppendInput{Body: "Investigating"}); err != nil {
+		t.Fatalf("AppendTimeline(note) error = %v", err)
+	}
+	if err := p.AppendTimeline(ctx, "PINC1", schema.TimelineAppendInput{Kind: "comment", Body: "Rolled back"}); err != nil {
+		t.Fatalf("AppendTimeline(comment) error = %v", err)
+	}
+	err := p.AppendTimeline(ctx, "PINC1", schema.TimelineAppendInput{
+		Kind: "status_update",
+		Body: "Checkout is degraded",
+		Metadata: map[string]any{
+			"subject":      "Checkout degraded",
+			"html_message": "<p>Checkout is degraded</p>",
+		},
+	})
+	if err != nil {
+		t.Fatalf("AppendTimeline(status_update) error = %v", err)
+	}
+
+	if want := []string{"/incidents/PINC1/notes", "/incidents/PINC1/notes", "/incidents/PINC1/status_updates"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+	if note, _ := bodies[0]["note"].(map[string]any); note["content"] != "Investigating" {
+		t.Errorf("note body = %v", bodies[0])
+	}
+	if note, _ := bodies[1]["note"].(map[string]any); note["content"] != "Rolled back" {
+		t.Errorf("other kinds must post a note, got %v", bodies[1])
+	}
+	wantUpdate := map[string]any{
+		"message":      "Checkout is degraded",
+		"subject":      "Checkout degraded",
+		"html_message": "<p>Checkout is degraded</p>",
+	}
+	if !reflect.DeepEqual(bodies[2], wantUpdate) {
+		t.Errorf("status update body = %v, want %v", bodies[2], wantUpdate)
+	}
+
+	for _, in := range []schema.TimelineAppendInput{
+		{Kind: "status_update"},
+		{Kind: "status_update", Body: "update", Metadata: map[string]any{"subject": "only a subject"}},
+	} {
+		if err := p.AppendTimeline(ctx, "PINC1", in); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("AppendTimeline(%+v) error = %v, want validation error", in, err)
+		}
+	}
+	if len(paths) != 3 {
+		t.Errorf("invalid entries must not be sent, got %d requests", len(paths))
+	}
+}
+
+func TestSubscribers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/incidents/PINC1/status_updates/subscribers" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"subscribers": []map[string]any{
+				{"subscriber_id": "PUSER1", "subscriber_type": "user", "has_indirect_subscription": false},
+				{
+					"subscriber_id": "PTEAM1", "subscriber_type": "team", "has_indirect_subscription": true,
+					"subscribed_via": []map[string]any{{"name": "Checkout", "type": "business_service"}},
+				},
+			},
+			"account_is_subscribed": false,
+		})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{cfg: Config{APIToken: "token", APIURL: server.URL}, client: &http.Client{}}
+	subs, err := p.Subscribers(context.Background(), "PINC1")
+	if err != nil {
+		t.Fatalf("Subscribers() error = %v", err)
+	}
+	want := []Subscriber{
+		{ID: "PUSER1", Type: "user"},
+		{ID: "PTEAM1", Type: "team", Indirect: true, SubscribedVia: []map[string]string{{"name": "Checkout", "type": "business_service"}}},
+	}
+	if !reflect.DeepEqual(subs, want) {
+		t.Errorf("Subscribers() = %+v, want %+v", subs, want)
+	}
+
+	if _, err := p.Subscribers(context.Background(), "MISSING"); !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestConvertPDLogEntry(t *testing.T) {
+	var notify, annotate, escalate pdLogEntry
+	mustUnmarshal(t, `{
+		"id": "L1",
+		"type": "notify_log_entry",
+		"summary": "Notified Jane Doe by SMS",
+		"created_at": "2025-01-01T10:05:00Z",
+		"html_url": "https://example.pagerduty.com/log_entries/L1",
+		"agent": {"id": "PSVC1", "type": "service_reference", "summary": "Checkout"},
+		"channel": {"type": "auto"},
+		"user": {"id": "PUSER1", "type": "user_reference", "summary": "Jane Doe"},
+		"notification": {"type": "sms_notification", "status": "success", "address": "+15550100"}
+	}`, &notify)
+	mustUnmarshal(t, `{
+		"id": "L2",
+		"type": "annotate_log_entry",
+		"summary": "Note added",
+		"created_at": "2025-01-01T10:10:00Z",
+		"agent": {"id": "PUSER2", "type": "user_reference", "summary": "John Roe"},
+		"channel": {"type": "note", "summary": "Rolled back the deploy"}
+	}`, &annotate)
+	mustUnmarshal(t, `{
+		"id": "L3",
+		"type": "escalate_log_entry",
+		"summary": "Escalated to level 2",
+		"created_at": "2025-01-01T10:15:00Z",
+		"channel": {"type": "timeout"},
+		"assignees": [{"id": "PUSER3", "type": "user_reference", "summary": "Ops Lead"}],
+		"escalation_policy": {"id": "PEP1", "type": "escalation_policy_reference", "summary": "Checkout EP"},
+		"escalation_level": 2
+	}`, &escalate)
+
+	entry := convertPDLogEntry(notify, "PINC1")
+	if entry.Actor["id"] != "PSVC1" || entry.Actor["type"] != "service_reference" || entry.Actor["name"] != "Checkout" {
+		t.Errorf("Actor = %v", entry.Actor)
+	}
+	if entry.Metadata["html_url"] != "https://example.pagerduty.com/log_entries/L1" {
+		t.Errorf("html_url = %v", entry.Metadata["html_url"])
+	}
+	if user, _ := entry.Metadata["user"].(map[string]string); user["id"] != "PUSER1" {
+		t.Errorf("user = %v", entry.Metadata["user"])
+	}
+	wantNotification := map[string]string{"type": "sms_notification", "status": "success", "address": "+15550100"}
+	if !reflect.DeepEqual(entry.Metadata["notification"], wantNotification) {
+		t.Errorf("notification = %v, want %v", entry.Metadata["notification"], wantNotification)
+	}
+	if !reflect.DeepEqual(entry.Metadata["channel"], map[string]any{"type": "auto"}) {
+		t.Errorf("channel = %v", entry.Metadata["channel"])
+	}
+
+	entry = convertPDLogEntry(annotate, "PINC1")
+	if entry.Body != "Rolled back the deploy" || entry.Metadata["note"] != "Rolled back the deploy" {
+		t.Errorf("annotate entry Body = %q, note = %v", entry.Body, entry.Metadata["note"])
+	}
+
+	entry = convertPDLogEntry(escalate, "PINC1")
+	if entry.Metadata["escalation_level"] != 2 {
+		t.Errorf("escalation_level = %v, want 2", entry.Metadata["escalation_level"])
+	}
+	if ep, _ := entry.Metadata["escalation_policy"].(map[string]string); ep["name"] != "Checkout EP" {
+		t.Errorf("escalation_policy = %v", entry.Metadata["escalation_policy"])
+	}
+	if assignees, _ := entry.Metadata["assignees"].([]map[string]string); len(assignees) != 1 || assignees[0]["id"] != "PUSER3" {
+		t.Errorf("assignees = %v", entry.Metadata["assignees"])
+	}
+	if entry.Actor != nil {
+		t.Errorf("Actor = %v, want nil without an agent", entry.Actor)
+	}
+
+	var merge pdLogEntry
+	mustUnmarshal(t, `{
+		"id": "L4",
+		"type": "merge_log_entry",
+		"summary": "Merged PSRC1 into this incident",
+		"created_at": "2025-01-01T10:20:00Z",
+		"source_incidents": [{"id": "PSRC1", "type": "incident_reference", "summary": "Checkout errors", "html_url": "https://example.pagerduty.com/incidents/PSRC1"}]
+	}`, &merge)
+	entry = convertPDLogEntry(merge, "PINC1")
+	if entry.Kind != "merged" {
+		t.Errorf("merge entry Kind = %q, want merged", entry.Kind)
+	}
+	want := []map[string]string{{"id": "PSRC1", "name": "Checkout errors", "html_url": "https://example.pagerduty.com/incidents/PSRC1"}}
+	if !reflect.DeepEqual(entry.Metadata["merged_incidents"], want) {
+		t.Errorf("merged_incidents = %v, want %v", entry.Metadata["merged_incidents"], want)
+	}
+}
+
+func mustUnmarshal(t *testing.T, data string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+}
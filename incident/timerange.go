@@ -0,0 +1,99 @@
+package incident
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+// maxQueryWindow is the longest since/until range PagerDuty accepts on the
+// incidents list endpoint (six months); longer ranges are split.
+const maxQueryWindow = 180 * 24 * time.Hour
+
+// timeWindow is one since/until range sent to GET /incidents. The zero value
+// sends neither and leaves PagerDuty's default window in place.
+type timeWindow struct {
+	since, until time.Time
+	all          bool
+}
+
+// apply sets the window parameters, replacing those of a previous window.
+func (w timeWindow) apply(params url.Values) {
+	params.Del("since")
+	params.Del("until")
+	params.Del("date_range")
+	if w.all {
+		params.Set("date_range", "all")
+		return
+	}
+	if !w.since.IsZero() {
+		params.Set("since", w.since.UTC().Format(time.RFC3339))
+	}
+	if !w.until.IsZero() {
+		params.Set("until", w.until.UTC().Format(time.RFC3339))
+	}
+}
+
+// queryWindows reads since, until and date_range from query metadata and
+// returns the windows to query, oldest first. date_range "all" searches the
+// whole history in one request. A since without until runs to now, and
+// ranges longer than maxQueryWindow are split into consecutive windows.
+func queryWindows(md map[string]any, now time.Time) ([]timeWindow, error) {
+	if strings.EqualFold(metadataString(md, "date_range"), "all") {
+		return []timeWindow{{all: true}}, nil
+	}
+	since, err := metadataTime(md, "since")
+	if err != nil {
+		return nil, err
+	}
+	until, err := metadataTime(md, "until")
+	if err != nil {
+		return nil, err
+	}
+	if since.IsZero() {
+		return []timeWindow{{until: until}}, nil
+	}
+	if until.IsZero() {
+		until = now
+	}
+	if !since.Before(until) {
+		return nil, common.Invalid(fmt.Errorf("since %s must be before until %s", since.Format(time.RFC3339), until.Format(time.RFC3339)))
+	}
+
+	var windows []timeWindow
+	for start := since; start.Before(until); start = start.Add(maxQueryWindow) {
+		end := start.Add(maxQueryWindow)
+		if end.After(until) {
+			end = until
+		}
+		windows = append(windows, timeWindow{since: start, until: end})
+	}
+	return windows, nil
+}
+
+// metadataTime reads a timestamp given as time.Time, an RFC 3339 string or a
+// date (2006-01-02). A missing key returns the zero time.
+func metadataTime(md map[string]any, key string) (time.Time, error) {
+	switch v := md[key].(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return time.Time{}, nil
+		}
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, common.Invalid(fmt.Errorf("%s %q is not an RFC 3339 timestamp or date", key, s))
+	default:
+		return time.Time{}, common.Invalid(fmt.Errorf("%s must be a timestamp string, got %T", key, v))
+	}
+}
@@ -0,0 +1,116 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+func TestQueryWindows(t *testing.T) {
+	now := time.Date(2025, 11, 21, 12, 0, 0, 0, time.UTC)
+
+	t.Run("no range", func(t *testing.T) {
+		windows, err := queryWindows(nil, now)
+		if err != nil {
+			t.Fatalf("queryWindows() error = %v", err)
+		}
+		params := url.Values{}
+		windows[0].apply(params)
+		if len(windows) != 1 || len(params) != 0 {
+			t.Errorf("expected a single unbounded window, got %v / %v", windows, params)
+		}
+	})
+
+	t.Run("date_range all", func(t *testing.T) {
+		windows, _ := queryWindows(map[string]any{"date_range": "all", "since": "2020-01-01"}, now)
+		params := url.Values{}
+		windows[0].apply(params)
+		if len(windows) != 1 || params.Get("date_range") != "all" || params.Has("since") {
+			t.Errorf("unexpected params %v", params)
+		}
+	})
+
+	t.Run("since defaults until to now", func(t *testing.T) {
+		windows, _ := queryWindows(map[string]any{"since": "2025-11-01T00:00:00Z"}, now)
+		params := url.Values{}
+		windows[0].apply(params)
+		if params.Get("since") != "2025-11-01T00:00:00Z" || params.Get("until") != "2025-11-21T12:00:00Z" {
+			t.Errorf("unexpected params %v", params)
+		}
+	})
+
+	t.Run("long ranges are split", func(t *testing.T) {
+		windows, err := queryWindows(map[string]any{"since": "2024-01-01", "until": "2025-03-01"}, now)
+		if err != nil {
+			t.Fatalf("queryWindows() error = %v", err)
+		}
+		if len(windows) != 3 {
+			t.Fatalf("expected 3 windows, got %d", len(windows))
+		}
+		for i, w := range windows {
+			if w.until.Sub(w.since) > maxQueryWindow {
+				t.Errorf("window %d spans %s", i, w.until.Sub(w.since))
+			}
+			if i > 0 && !w.since.Equal(windows[i-1].until) {
+				t.Errorf("window %d does not start where window %d ends", i, i-1)
+			}
+		}
+		if !windows[2].until.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("last window ends at %s", windows[2].until)
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		for _, md := range []map[string]any{
+			{"since": "yesterday"},
+			{"since": "2025-02-01", "until": "2025-01-01"},
+			{"until": 42},
+		} {
+			if _, err := queryWindows(md, now); !errors.Is(err, common.ErrValidation) {
+				t.Errorf("queryWindows(%v) error = %v, want ErrValidation", md, err)
+			}
+		}
+	})
+}
+
+func TestQuerySplitsLongRanges(t *testing.T) {
+	var windows [][2]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		windows = append(windows, [2]string{query.Get("since"), query.Get("until")})
+		// The boundary incident is returned by two windows and must be deduped.
+		incidents := []map[string]any{{"id": "PBOUNDARY", "status": "resolved"}}
+		incidents = append(incidents, map[string]any{"id": "P" + query.Get("since")[:10], "status": "resolved"})
+		json.NewEncoder(w).Encode(map[string]any{"incidents": incidents})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{Source: "pagerduty", APIToken: "test-token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+
+	incidents, err := p.Query(context.Background(), schema.IncidentQuery{
+		Metadata: map[string]any{"since": "2024-01-01T00:00:00Z", "until": "2025-01-01T00:00:00Z"},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(windows) != 3 {
+		t.Fatalf("expected 3 requests, got %v", windows)
+	}
+	if windows[0][0] != "2024-01-01T00:00:00Z" || windows[2][1] != "2025-01-01T00:00:00Z" {
+		t.Errorf("unexpected windows %v", windows)
+	}
+	if len(incidents) != 4 {
+		t.Errorf("expected 4 unique incidents, got %d", len(incidents))
+	}
+}
@@ -0,0 +1,175 @@
+package incident
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+// lookupFunc resolves a name to the matching PagerDuty objects.
+type lookupFunc func(ctx context.Context, c *common.Client, name string, opts common.LookupOptions) (common.LookupResults, error)
+
+// buildUpdatePayload translates an update into the PagerDuty incident body.
+// Besides the typed fields it maps these metadata keys:
+//
+//	assignee_ids / assignees                  -> assignments (user IDs / names or emails)
+//	escalation_policy_id / escalation_policy  -> escalation_policy
+//	escalation_level                          -> escalation_level
+//	priority_id / priority                    -> priority (else from Severity via severityToPriority)
+//	resolution                                -> resolution (requires status resolved)
+//	service_id                                -> service (in.Service is resolved by name)
+//
+// Names are resolved through the PagerDuty list endpoints and must match
+// exactly one object.
+func (p *PagerDutyProvider) buildUpdatePayload(ctx context.Context, in schema.UpdateIncidentInput) (map[string]any, error) {
+	body := map[string]any{
+		"type": "incident",
+	}
+
+	if in.Title != nil {
+		body["title"] = *in.Title
+	}
+	if in.Status != nil {
+		body["status"] = p.cfg.Mappings.pdStatus(*in.Status)
+	}
+	if in.Severity != nil {
+		body["urgency"] = p.cfg.Mappings.urgency(*in.Severity)
+	}
+
+	assigneeIDs := metadataStrings(in.Metadata, "assignee_ids")
+	for _, name := range metadataStrings(in.Metadata, "assignees") {
+		id, err := p.resolveID(ctx, "user", name, common.LookupUsers)
+		if err != nil {
+			return nil, err
+		}
+		assigneeIDs = append(assigneeIDs, id)
+	}
+	if len(assigneeIDs) > 0 {
+		assignments := make([]map[string]any, len(assigneeIDs))
+		for i, id := range assigneeIDs {
+			assignments[i] = map[string]any{
+				"assignee": reference(id, "user_reference"),
+			}
+		}
+		body["assignments"] = assignments
+	}
+
+	// PagerDuty rejects assignments together with an escalation policy.
+	if id, err := p.metadataID(ctx, in.Metadata, "escalation_policy", common.LookupEscalationPolicies); err != nil {
+		return nil, err
+	} else if id != "" {
+		if len(assigneeIDs) > 0 {
+			return nil, common.Invalid(errors.New("assignees cannot be combined with escalation_policy"))
+		}
+		body["escalation_policy"] = reference(id, "escalation_policy_reference")
+	}
+
+	if v, ok := common.IntFromConfig(in.Metadata["escalation_level"]); ok {
+		if v < 1 {
+			return nil, common.Invalid(fmt.Errorf("escalation_level must be at least 1, got %d", v))
+		}
+		body["escalation_level"] = v
+	}
+
+	if id, err := p.metadataID(ctx, in.Metadata, "priority", common.LookupPriorities); err != nil {
+		return nil, err
+	} else if id != "" {
+		body["priority"] = reference(id, "priority_reference")
+	} else if in.Severity != nil {
+		id, err := p.priorityID(ctx, *in.Severity)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			body["priority"] = reference(id, "priority_reference")
+		}
+	}
+
+	if v := metadataString(in.Metadata, "resolution"); v != "" {
+		if body["status"] != "resolved" {
+			return nil, common.Invalid(errors.New("resolution requires status resolved"))
+		}
+		body["resolution"] = v
+	}
+
+	serviceID := metadataString(in.Metadata, "service_id")
+	if serviceID == "" && in.Service != nil && *in.Service != "" {
+		id, err := p.resolveID(ctx, "service", *in.Service, common.LookupServices)
+		if err != nil {
+			return nil, err
+		}
+		serviceID = id
+	}
+	if serviceID != "" {
+		body["service"] = reference(serviceID, "service_reference")
+	}
+
+	return map[string]any{"incident": body}, nil
+}
+
+// metadataID returns the ID given as <key>_id, or resolves the name given as
+// <key>. It returns "" when neither is set.
+func (p *PagerDutyProvider) metadataID(ctx context.Context, m map[string]any, key string, lookup lookupFunc) (string, error) {
+	if id := metadataString(m, key+"_id"); id != "" {
+		return id, nil
+	}
+	name := metadataString(m, key)
+	if name == "" {
+		return "", nil
+	}
+	return p.resolveID(ctx, strings.ReplaceAll(key, "_", " "), name, lookup)
+}
+
+// resolveID looks up name and requires exactly one match. Lookups are always
+// strict here: an update must never pick one of several candidates.
+func (p *PagerDutyProvider) resolveID(ctx context.Context, kind, name string, lookup lookupFunc) (string, error) {
+	opts := p.cfg.Lookup
+	opts.Strict = true
+	matches, err := lookup(ctx, p.api(), name, opts)
+	if err != nil {
+		return "", fmt.Errorf("lookup %s by name %q: %w", kind, name, err)
+	}
+	switch len(matches) {
+	case 0:
+		return "", common.Invalid(fmt.Errorf("no pagerduty %s matches %q", kind, name))
+	case 1:
+		return matches[0].ID, nil
+	default:
+		return "", common.Invalid(fmt.Errorf("pagerduty %s name %q is ambiguous: %d matches", kind, name, len(matches)))
+	}
+}
+
+// reference builds a PagerDuty object reference.
+func reference(id, typ string) map[string]string {
+	return map[string]string{"id": id, "type": typ}
+}
+
+// metadataStrings reads a string or list of strings from metadata.
+func metadataStrings(m map[string]any, key string) []string {
+	var out []string
+	switch v := m[key].(type) {
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	case []string:
+		for _, s := range v {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,497 @@
+package oncall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+// maxPageSize is the largest page size accepted by the PagerDuty list endpoints.
+const maxPageSize = 100
+
+// Config captures decrypted configuration from OpsOrch Core.
+type Config struct {
+	Source   string
+	APIToken string
+	APIURL   string
+	Retry    common.RetryPolicy
+	// RateLimitPerMinute caps outgoing requests per API token; 0 disables it.
+	RateLimitPerMinute int
+	Lookup             common.LookupOptions // name matching for Scope lookups
+}
+
+// Query selects the service whose on-call responders should be resolved.
+// Either ServiceID or Service (a service name) must be set. Since and Until
+// are optional; without them PagerDuty returns who is on call right now.
+type Query struct {
+	ServiceID string    `json:"serviceId,omitempty"`
+	Service   string    `json:"service,omitempty"`
+	Since     time.Time `json:"since,omitempty"`
+	Until     time.Time `json:"until,omitempty"`
+}
+
+// ScheduleQuery selects schedules and the time window to render them for.
+// The window defaults to the next seven days.
+type ScheduleQuery struct {
+	Query string    `json:"query,omitempty"`
+	IDs   []string  `json:"ids,omitempty"`
+	Since time.Time `json:"since,omitempty"`
+	Until time.Time `json:"until,omitempty"`
+	Limit int       `json:"limit,omitempty"`
+}
+
+// User is a PagerDuty user reference.
+type User struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	HTMLURL string `json:"htmlUrl,omitempty"`
+}
+
+// Shift is a single on-call assignment.
+type Shift struct {
+	User         User       `json:"user"`
+	ScheduleID   string     `json:"scheduleId,omitempty"`
+	ScheduleName string     `json:"scheduleName,omitempty"`
+	Start        *time.Time `json:"start,omitempty"`
+	End          *time.Time `json:"end,omitempty"`
+}
+
+// Level groups the responders of one escalation level.
+type Level struct {
+	Level  int     `json:"level"`
+	Shifts []Shift `json:"shifts"`
+}
+
+// ServiceOnCall answers "who is on call for this service".
+type ServiceOnCall struct {
+	ServiceID            string         `json:"serviceId"`
+	ServiceName          string         `json:"serviceName"`
+	EscalationPolicyID   string         `json:"escalationPolicyId"`
+	EscalationPolicyName string         `json:"escalationPolicyName"`
+	Levels               []Level        `json:"levels"`
+	Metadata             map[string]any `json:"metadata,omitempty"`
+}
+
+// ScheduleLayer is one rotation layer of a schedule.
+type ScheduleLayer struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	Start              time.Time `json:"start"`
+	RotationTurnLength int       `json:"rotationTurnLengthSeconds"`
+	Users              []User    `json:"users"`
+	Entries            []Shift   `json:"entries"`
+}
+
+// Schedule is a PagerDuty schedule rendered over a time window.
+type Schedule struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	TimeZone  string          `json:"timeZone"`
+	Layers    []ScheduleLayer `json:"layers"`
+	Overrides []Shift         `json:"overrides"`
+	Final     []Shift         `json:"final"`
+	Metadata  map[string]any  `json:"metadata,omitempty"`
+}
+
+// PagerDutyProvider resolves on-call responders and schedules. OpsOrch Core
+// has no on-call capability yet, so the provider is exposed through its own
+// plugin binary rather than a Core registry.
+type PagerDutyProvider struct {
+	cfg     Config
+	client  *http.Client
+	limiter *common.RateLimiter
+}
+
+// New constructs the provider from decrypted config.
+func New(cfg map[string]any) (*PagerDutyProvider, error) {
+	parsed := parseConfig(cfg)
+	if parsed.APIToken == "" {
+		return nil, errors.New("pagerduty apiToken is required")
+	}
+	if parsed.APIURL == "" {
+		return nil, errors.New("pagerduty apiURL is required")
+	}
+	if err := parsed.Lookup.Validate(); err != nil {
+		return nil, err
+	}
+	var limiter *common.RateLimiter
+	if parsed.RateLimitPerMinute > 0 {
+		limiter = common.SharedRateLimiter(parsed.APIToken, parsed.RateLimitPerMinute)
+	}
+	return &PagerDutyProvider{
+		cfg:     parsed,
+		client:  common.NewHTTPClient(limiter),
+		limiter: limiter,
+	}, nil
+}
+
+// ServiceOnCall resolves the service's escalation policy and returns the
+// responders on call for each escalation level.
+func (p *PagerDutyProvider) ServiceOnCall(ctx context.Context, q Query) (ServiceOnCall, error) {
+	serviceID := q.ServiceID
+	if serviceID == "" {
+		if q.Service == "" {
+			return ServiceOnCall{}, common.Invalid(errors.New("serviceId or service is required"))
+		}
+		opts := p.cfg.Lookup
+		opts.Strict = true
+		services, err := common.LookupServices(ctx, p.api(), q.Service, opts)
+		if err != nil {
+			return ServiceOnCall{}, fmt.Errorf("lookup service by name %q: %w", q.Service, err)
+		}
+		// Strict lookups already fail when the name matches several services.
+		if len(services) == 0 {
+			return ServiceOnCall{}, fmt.Errorf("service %q: %w", q.Service, common.ErrNotFound)
+		}
+		serviceID = services[0].ID
+	}
+
+	var svc struct {
+		Service struct {
+			ID               string `json:"id"`
+			Name             string `json:"name"`
+			EscalationPolicy struct {
+				ID      string `json:"id"`
+				Summary string `json:"summary"`
+			} `json:"escalation_policy"`
+		} `json:"service"`
+	}
+	if err := p.api().Get(ctx, "/services/"+serviceID, nil, &svc); err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			return ServiceOnCall{}, fmt.Errorf("service %s not found: %w", serviceID, err)
+		}
+		return ServiceOnCall{}, err
+	}
+
+	out := ServiceOnCall{
+		ServiceID:            svc.Service.ID,
+		ServiceName:          svc.Service.Name,
+		EscalationPolicyID:   svc.Service.EscalationPolicy.ID,
+		EscalationPolicyName: svc.Service.EscalationPolicy.Summary,
+		Levels:               []Level{},
+		Metadata:             map[string]any{"source": p.cfg.Source},
+	}
+	if out.EscalationPolicyID == "" {
+		return out, nil
+	}
+
+	params := url.Values{}
+	params.Add("escalation_policy_ids[]", out.EscalationPolicyID)
+	params.Add("include[]", "users")
+	if !q.Since.IsZero() {
+		params.Set("since", q.Since.UTC().Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		params.Set("until", q.Until.UTC().Format(time.RFC3339))
+	}
+
+	oncalls, err := p.listOnCalls(ctx, params)
+	if err != nil {
+		return ServiceOnCall{}, err
+	}
+
+	byLevel := map[int]*Level{}
+	for _, oc := range oncalls {
+		lvl, ok := byLevel[oc.EscalationLevel]
+		if !ok {
+			lvl = &Level{Level: oc.EscalationLevel}
+			byLevel[oc.EscalationLevel] = lvl
+		}
+		shift := Shift{
+			User:         convertUser(oc.User),
+			ScheduleID:   oc.Schedule.ID,
+			ScheduleName: oc.Schedule.Summary,
+			Start:        parseTime(oc.Start),
+			End:          parseTime(oc.End),
+		}
+		lvl.Shifts = append(lvl.Shifts, shift)
+	}
+	for _, lvl := range byLevel {
+		out.Levels = append(out.Levels, *lvl)
+	}
+	sort.Slice(out.Levels, func(i, j int) bool { return out.Levels[i].Level < out.Levels[j].Level })
+
+	return out, nil
+}
+
+// Schedules lists schedules matching the query and renders their layers,
+// overrides and final schedule over the requested window.
+func (p *PagerDutyProvider) Schedules(ctx context.Context, q ScheduleQuery) ([]Schedule, error) {
+	since, until := q.Since, q.Until
+	if since.IsZero() {
+		since = time.Now()
+	}
+	if until.IsZero() {
+		until = since.Add(7 * 24 * time.Hour)
+	}
+	if !until.After(since) {
+		return nil, common.Invalid(errors.New("until must be after since"))
+	}
+
+	ids := q.IDs
+	if len(ids) == 0 {
+		var err error
+		ids, err = p.listScheduleIDs(ctx, q.Query, q.Limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	window := url.Values{}
+	window.Set("since", since.UTC().Format(time.RFC3339))
+	window.Set("until", until.UTC().Format(time.RFC3339))
+
+	schedules := make([]Schedule, 0, len(ids))
+	for _, id := range ids {
+		var result struct {
+			Schedule pdSchedule `json:"schedule"`
+		}
+		if err := p.api().Get(ctx, "/schedules/"+id, window, &result); err != nil {
+			if errors.Is(err, common.ErrNotFound) {
+				return nil, fmt.Errorf("schedule %s not found: %w", id, err)
+			}
+			return nil, err
+		}
+		schedules = append(schedules, convertPDSchedule(result.Schedule, p.cfg.Source))
+	}
+
+	return schedules, nil
+}
+
+// RateLimitBudget reports the client-side request budget when a rate limit is
+// configured.
+func (p *PagerDutyProvider) RateLimitBudget() (common.RateLimitBudget, bool) {
+	if p.limiter == nil {
+		return common.RateLimitBudget{}, false
+	}
+	return p.limiter.Budget(), true
+}
+
+func (p *PagerDutyProvider) listOnCalls(ctx context.Context, params url.Values) ([]pdOnCall, error) {
+	var oncalls []pdOnCall
+	offset := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		params.Set("limit", strconv.Itoa(maxPageSize))
+		params.Set("offset", strconv.Itoa(offset))
+
+		var result struct {
+			OnCalls []pdOnCall `json:"oncalls"`
+			More    bool       `json:"more"`
+		}
+		if err := p.api().Get(ctx, "/oncalls", params, &result); err != nil {
+			return nil, err
+		}
+		oncalls = append(oncalls, result.OnCalls...)
+		offset += len(result.OnCalls)
+		if !result.More || len(result.OnCalls) == 0 {
+			break
+		}
+	}
+	return oncalls, nil
+}
+
+func (p *PagerDutyProvider) listScheduleIDs(ctx context.Context, query string, limit int) ([]string, error) {
+	params := url.Values{}
+	if query != "" {
+		params.Set("query", query)
+	}
+
+	var ids []string
+	offset := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		params.Set("limit", strconv.Itoa(maxPageSize))
+		params.Set("offset", strconv.Itoa(offset))
+
+		var result struct {
+			Schedules []struct {
+				ID string `json:"id"`
+			} `json:"schedules"`
+			More bool `json:"more"`
+		}
+		if err := p.api().Get(ctx, "/schedules", params, &result); err != nil {
+			return nil, err
+		}
+		for _, s := range result.Schedules {
+			ids = append(ids, s.ID)
+			if limit > 0 && len(ids) >= limit {
+				return ids, nil
+			}
+		}
+		offset += len(result.Schedules)
+		if !result.More || len(result.Schedules) == 0 {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// api returns a PagerDuty client bound to the provider's configuration.
+func (p *PagerDutyProvider) api() *common.Client {
+	c := common.NewClient(p.client, p.cfg.APIURL, p.cfg.APIToken)
+	c.Retry = p.cfg.Retry
+	return c
+}
+
+func parseConfig(cfg map[string]any) Config {
+	out := Config{
+		Source: "pagerduty",
+		APIURL: "https://api.pagerduty.com",
+	}
+	if v, ok := cfg["source"].(string); ok && v != "" {
+		out.Source = v
+	}
+	if v, ok := cfg["apiToken"].(string); ok {
+		out.APIToken = strings.TrimSpace(v)
+	}
+	if v, ok := cfg["apiURL"].(string); ok && v != "" {
+		out.APIURL = strings.TrimSpace(v)
+	}
+	if v, ok := common.IntFromConfig(cfg["rateLimitPerMinute"]); ok && v > 0 {
+		out.RateLimitPerMinute = v
+	}
+	out.Retry = common.ParseRetryPolicy(cfg)
+	out.Lookup = common.ParseLookupOptions(cfg)
+	return out
+}
+
+// pdUser represents a PagerDuty user or user reference.
+type pdUser struct {
+	ID      string `json:"id"`
+	Summary string `json:"summary"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	HTMLURL string `json:"html_url"`
+}
+
+// pdOnCall represents an entry of GET /oncalls.
+type pdOnCall struct {
+	EscalationLevel int    `json:"escalation_level"`
+	User            pdUser `json:"user"`
+	Schedule        struct {
+		ID      string `json:"id"`
+		Summary string `json:"summary"`
+	} `json:"schedule"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// pdScheduleEntry is a rendered entry of a schedule layer or sub-schedule.
+type pdScheduleEntry struct {
+	User  pdUser `json:"user"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// pdSchedule represents a PagerDuty schedule rendered for a time window.
+type pdSchedule struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	TimeZone       string `json:"time_zone"`
+	HTMLURL        string `json:"html_url"`
+	ScheduleLayers []struct {
+		ID                        string `json:"id"`
+		Name                      string `json:"name"`
+		Start                     string `json:"start"`
+		RotationTurnLengthSeconds int    `json:"rotation_turn_length_seconds"`
+		Users                     []struct {
+			User pdUser `json:"user"`
+		} `json:"users"`
+		RenderedScheduleEntries []pdScheduleEntry `json:"rendered_schedule_entries"`
+	} `json:"schedule_layers"`
+	OverridesSubschedule struct {
+		RenderedScheduleEntries []pdScheduleEntry `json:"rendered_schedule_entries"`
+	} `json:"overrides_subschedule"`
+	FinalSchedule struct {
+		RenderedScheduleEntries []pdScheduleEntry `json:"rendered_schedule_entries"`
+	} `json:"final_schedule"`
+}
+
+func convertPDSchedule(s pdSchedule, source string) Schedule {
+	out := Schedule{
+		ID:        s.ID,
+		Name:      s.Name,
+		TimeZone:  s.TimeZone,
+		Layers:    make([]ScheduleLayer, 0, len(s.ScheduleLayers)),
+		Overrides: convertEntries(s.OverridesSubschedule.RenderedScheduleEntries),
+		Final:     convertEntries(s.FinalSchedule.RenderedScheduleEntries),
+		Metadata: map[string]any{
+			"source":      source,
+			"description": s.Description,
+			"html_url":    s.HTMLURL,
+		},
+	}
+	for _, l := range s.ScheduleLayers {
+		layer := ScheduleLayer{
+			ID:                 l.ID,
+			Name:               l.Name,
+			RotationTurnLength: l.RotationTurnLengthSeconds,
+			Users:              make([]User, 0, len(l.Users)),
+			Entries:            convertEntries(l.RenderedScheduleEntries),
+		}
+		if start := parseTime(l.Start); start != nil {
+			layer.Start = *start
+		}
+		for _, u := range l.Users {
+			layer.Users = append(layer.Users, convertUser(u.User))
+		}
+		out.Layers = append(out.Layers, layer)
+	}
+	return out
+}
+
+func convertEntries(entries []pdScheduleEntry) []Shift {
+	out := make([]Shift, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, Shift{
+			User:  convertUser(e.User),
+			Start: parseTime(e.Start),
+			End:   parseTime(e.End),
+		})
+	}
+	return out
+}
+
+func convertUser(u pdUser) User {
+	return User{
+		ID:      u.ID,
+		Name:    defaultString(u.Name, u.Summary),
+		Email:   u.Email,
+		HTMLURL: u.HTMLURL,
+	}
+}
+
+// parseTime returns nil for empty or invalid timestamps; PagerDuty leaves
+// start/end empty for permanent on-call assignments.
+func parseTime(v string) *time.Time {
+	if v == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func defaultString(val string, fallback string) string {
+	if val != "" {
+		return val
+	}
+	return fallback
+}
@@ -0,0 +1,199 @@
+package oncall
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
+)
+
+func TestNewRequiresCredentials(t *testing.T) {
+	if _, err := New(map[string]any{}); err == nil {
+		t.Fatalf("expected error when apiToken missing")
+	}
+	if _, err := New(map[string]any{"apiToken": "token"}); err != nil {
+		t.Fatalf("expected success with apiToken, got: %v", err)
+	}
+}
+
+func TestServiceOnCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/services":
+			json.NewEncoder(w).Encode(map[string]any{
+				"services": []map[string]any{{"id": "SVC1", "name": "payments"}, {"id": "SVC2", "name": "payments-eu"}},
+			})
+		case "/services/SVC1":
+			json.NewEncoder(w).Encode(map[string]any{
+				"service": map[string]any{
+					"id":   "SVC1",
+					"name": "payments",
+					"escalation_policy": map[string]any{
+						"id":      "EP1",
+						"summary": "Payments EP",
+					},
+				},
+			})
+		case "/oncalls":
+			query := r.URL.Query()
+			if got := query["escalation_policy_ids[]"]; len(got) != 1 || got[0] != "EP1" {
+				t.Errorf("escalation_policy_ids[] = %v, want [EP1]", got)
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"oncalls": []map[string]any{
+					{
+						"escalation_level": 2,
+						"user":             map[string]any{"id": "U2", "name": "Bob", "email": "bob@example.com"},
+					},
+					{
+						"escalation_level": 1,
+						"user":             map[string]any{"id": "U1", "summary": "Alice"},
+						"schedule":         map[string]any{"id": "SCH1", "summary": "Primary"},
+						"start":            "2025-11-21T00:00:00Z",
+						"end":              "2025-11-22T00:00:00Z",
+					},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{Source: "pagerduty", APIToken: "token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+	ctx := context.Background()
+
+	t.Run("by service id", func(t *testing.T) {
+		res, err := p.ServiceOnCall(ctx, Query{ServiceID: "SVC1"})
+		if err != nil {
+			t.Fatalf("ServiceOnCall() error = %v", err)
+		}
+		if res.EscalationPolicyID != "EP1" {
+			t.Errorf("EscalationPolicyID = %s, want EP1", res.EscalationPolicyID)
+		}
+		if len(res.Levels) != 2 || res.Levels[0].Level != 1 || res.Levels[1].Level != 2 {
+			t.Fatalf("unexpected levels %+v", res.Levels)
+		}
+		first := res.Levels[0].Shifts[0]
+		if first.User.Name != "Alice" || first.ScheduleName != "Primary" || first.Start == nil {
+			t.Errorf("unexpected first shift %+v", first)
+		}
+		if res.Levels[1].Shifts[0].Start != nil {
+			t.Errorf("permanent on-call should have no start")
+		}
+	})
+
+	t.Run("by service name", func(t *testing.T) {
+		res, err := p.ServiceOnCall(ctx, Query{Service: "payments"})
+		if err != nil {
+			t.Fatalf("ServiceOnCall() error = %v", err)
+		}
+		if res.ServiceID != "SVC1" {
+			t.Errorf("ServiceID = %s, want SVC1", res.ServiceID)
+		}
+	})
+
+	t.Run("ambiguous service name", func(t *testing.T) {
+		_, err := p.ServiceOnCall(ctx, Query{Service: "pay"})
+		var ambiguous *common.AmbiguousError
+		if !errors.As(err, &ambiguous) || !errors.Is(err, common.ErrValidation) {
+			t.Errorf("ServiceOnCall() error = %v, want AmbiguousError", err)
+		}
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		if _, err := p.ServiceOnCall(ctx, Query{ServiceID: "MISSING"}); !errors.Is(err, common.ErrNotFound) {
+			t.Errorf("ServiceOnCall() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("requires service", func(t *testing.T) {
+		if _, err := p.ServiceOnCall(ctx, Query{}); !errors.Is(err, common.ErrValidation) {
+			t.Errorf("ServiceOnCall() error = %v, want ErrValidation", err)
+		}
+	})
+}
+
+func TestSchedules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schedules":
+			json.NewEncoder(w).Encode(map[string]any{
+				"schedules": []map[string]any{{"id": "SCH1"}},
+			})
+		case "/schedules/SCH1":
+			if r.URL.Query().Get("since") != "2025-11-21T00:00:00Z" || r.URL.Query().Get("until") != "2025-11-28T00:00:00Z" {
+				t.Errorf("unexpected window %s", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"schedule": map[string]any{
+					"id":        "SCH1",
+					"name":      "Primary",
+					"time_zone": "UTC",
+					"schedule_layers": []map[string]any{
+						{
+							"id":                           "L1",
+							"name":                         "Weekly",
+							"start":                        "2025-01-01T00:00:00Z",
+							"rotation_turn_length_seconds": 604800,
+							"users":                        []map[string]any{{"user": map[string]any{"id": "U1", "summary": "Alice"}}},
+							"rendered_schedule_entries": []map[string]any{
+								{"user": map[string]any{"id": "U1", "summary": "Alice"}, "start": "2025-11-21T00:00:00Z", "end": "2025-11-28T00:00:00Z"},
+							},
+						},
+					},
+					"overrides_subschedule": map[string]any{
+						"rendered_schedule_entries": []map[string]any{
+							{"user": map[string]any{"id": "U2", "summary": "Bob"}, "start": "2025-11-22T00:00:00Z", "end": "2025-11-23T00:00:00Z"},
+						},
+					},
+					"final_schedule": map[string]any{
+						"rendered_schedule_entries": []map[string]any{
+							{"user": map[string]any{"id": "U1", "summary": "Alice"}, "start": "2025-11-21T00:00:00Z", "end": "2025-11-22T00:00:00Z"},
+							{"user": map[string]any{"id": "U2", "summary": "Bob"}, "start": "2025-11-22T00:00:00Z", "end": "2025-11-23T00:00:00Z"},
+						},
+					},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{Source: "pagerduty", APIToken: "token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+
+	since := time.Date(2025, 11, 21, 0, 0, 0, 0, time.UTC)
+	schedules, err := p.Schedules(context.Background(), ScheduleQuery{Since: since})
+	if err != nil {
+		t.Fatalf("Schedules() error = %v", err)
+	}
+	if len(schedules) != 1 {
+		t.Fatalf("len(schedules) = %d, want 1", len(schedules))
+	}
+	s := schedules[0]
+	if len(s.Layers) != 1 || s.Layers[0].RotationTurnLength != 604800 || len(s.Layers[0].Users) != 1 {
+		t.Errorf("unexpected layers %+v", s.Layers)
+	}
+	if len(s.Overrides) != 1 || s.Overrides[0].User.Name != "Bob" {
+		t.Errorf("unexpected overrides %+v", s.Overrides)
+	}
+	if len(s.Final) != 2 {
+		t.Errorf("len(Final) = %d, want 2", len(s.Final))
+	}
+
+	if _, err := p.Schedules(context.Background(), ScheduleQuery{Since: since, Until: since}); !errors.Is(err, common.ErrValidation) {
+		t.Errorf("expected validation error for empty window, got %v", err)
+	}
+}
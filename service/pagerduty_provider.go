@@ -2,14 +2,13 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/opsorch/opsorch-core/schema"
 	coreservice "github.com/opsorch/opsorch-core/service"
@@ -19,17 +18,30 @@ import (
 // ProviderName is the registry key under which this adapter registers.
 const ProviderName = "pagerduty"
 
+// maxPageSize is the largest page size accepted by GET /services.
+const maxPageSize = 100
+
+// maxServicePages bounds Query when it has to read several pages, so a
+// misbehaving API cannot page forever.
+const maxServicePages = 100
+
 // Config captures decrypted configuration from OpsOrch Core.
 type Config struct {
 	Source   string
 	APIToken string
 	APIURL   string
+	Retry    common.RetryPolicy
+	// RateLimitPerMinute caps outgoing requests per API token; 0 disables it.
+	RateLimitPerMinute int
+	Lookup             common.LookupOptions // name matching for Scope lookups
+	Environments       common.Environments
 }
 
 // PagerDutyProvider integrates with PagerDuty REST API v2 for services.
 type PagerDutyProvider struct {
-	cfg    Config
-	client *http.Client
+	cfg     Config
+	client  *http.Client
+	limiter *common.RateLimiter
 }
 
 // New constructs the provider from decrypted config.
@@ -41,9 +53,21 @@ func New(cfg map[string]any) (coreservice.Provider, error) {
 	if parsed.APIURL == "" {
 		return nil, errors.New("pagerduty apiURL is required")
 	}
+	if err := parsed.Lookup.Validate(); err != nil {
+		return nil, err
+	}
+	if err := parsed.Environments.Compile(); err != nil {
+		return nil, err
+	}
+	parsed.Lookup.Cache = common.SharedLookupCache(parsed.APIURL, parsed.APIToken, parsed.Lookup.CacheConfig)
+	var limiter *common.RateLimiter
+	if parsed.RateLimitPerMinute > 0 {
+		limiter = common.SharedRateLimiter(parsed.APIToken, parsed.RateLimitPerMinute)
+	}
 	return &PagerDutyProvider{
-		cfg:    parsed,
-		client: &http.Client{Timeout: 30 * time.Second},
+		cfg:     parsed,
+		client:  common.NewHTTPClient(limiter),
+		limiter: limiter,
 	}, nil
 }
 
@@ -67,11 +91,15 @@ func (p *PagerDutyProvider) Query(ctx context.Context, q schema.ServiceQuery) ([
 
 	// Translate Scope.Team to PagerDuty team IDs via lookup
 	if q.Scope.Team != "" {
-		teamIDs, err := common.LookupTeamIDsByName(ctx, p.client, p.cfg.APIURL, p.cfg.APIToken, q.Scope.Team)
+		teams, err := common.LookupTeams(ctx, p.api(), q.Scope.Team, p.cfg.Lookup)
 		if err != nil {
 			return nil, fmt.Errorf("lookup team by name %q: %w", q.Scope.Team, err)
 		}
-		for _, id := range teamIDs {
+		// An unknown name must not widen the query to the whole account.
+		if len(teams) == 0 {
+			return []schema.Service{}, nil
+		}
+		for _, id := range teams.IDs() {
 			params.Add("team_ids[]", id)
 		}
 	}
@@ -83,40 +111,100 @@ func (p *PagerDutyProvider) Query(ctx context.Context, q schema.ServiceQuery) ([
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", p.cfg.APIURL+"/services?"+params.Encode(), nil)
+	order, err := common.ParseSort(q.Metadata, []string{"name", "created_at"})
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, err
+	}
+	if order.Field == "name" {
+		params.Set("sort_by", order.Param())
 	}
 
-	req.Header.Set("Authorization", "Token token="+p.cfg.APIToken)
-	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	var teamEnvs map[string]string
+	if p.cfg.Environments.TagPrefix != "" {
+		if teamEnvs, err = p.cfg.Environments.TeamEnvironments(ctx, p.api()); err != nil {
+			return nil, err
+		}
+	}
 
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("execute request: %w", err)
+	// The environment filter is applied here rather than by PagerDuty, so
+	// pages are read until Limit services match instead of filtering one page.
+	// PagerDuty cannot sort by created_at, so that sort reads every page
+	// before Limit applies.
+	envFilter := q.Scope.Environment != "" && p.cfg.Environments.Enabled()
+	localSort := order.Field == "created_at"
+	readAll := envFilter || localSort
+	if readAll {
+		params.Set("limit", strconv.Itoa(maxPageSize))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("pagerduty api error: %d %s", resp.StatusCode, string(bodyBytes))
+	var kept []pdService
+	for page, offset := 0, 0; page < maxServicePages; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		params.Set("offset", strconv.Itoa(offset))
+		var result struct {
+			Services []pdService `json:"services"`
+			More     bool        `json:"more"`
+		}
+		if err := p.api().Get(ctx, "/services", params, &result); err != nil {
+			return nil, err
+		}
+		for _, pdSvc := range result.Services {
+			if envFilter && !strings.EqualFold(serviceEnvironment(pdSvc, p.cfg, teamEnvs), q.Scope.Environment) {
+				continue
+			}
+			kept = append(kept, pdSvc)
+		}
+		offset += len(result.Services)
+		if !readAll || !result.More || len(result.Services) == 0 || (!localSort && q.Limit > 0 && len(kept) >= q.Limit) {
+			break
+		}
 	}
 
-	var result struct {
-		Services []pdService `json:"services"`
+	// PagerDuty only sorts services by name; created_at is ordered here.
+	if localSort {
+		slices.SortStableFunc(kept, func(a, b pdService) int {
+			if order.Desc {
+				a, b = b, a
+			}
+			return strings.Compare(a.CreatedAt, b.CreatedAt)
+		})
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	if q.Limit > 0 && len(kept) > q.Limit {
+		kept = kept[:q.Limit]
 	}
 
-	services := make([]schema.Service, len(result.Services))
-	for i, pdSvc := range result.Services {
-		services[i] = convertPDService(pdSvc, p.cfg.Source)
+	services := make([]schema.Service, 0, len(kept))
+	for _, pdSvc := range kept {
+		services = append(services, convertPDService(pdSvc, p.cfg, teamEnvs))
 	}
 
 	return services, nil
 }
 
+// RateLimitBudget reports the client-side request budget when a rate limit is
+// configured.
+func (p *PagerDutyProvider) RateLimitBudget() (common.RateLimitBudget, bool) {
+	if p.limiter == nil {
+		return common.RateLimitBudget{}, false
+	}
+	return p.limiter.Budget(), true
+}
+
+// InvalidateLookupCache drops cached name lookups for kind and name (empty
+// values match everything) and returns how many entries were removed.
+func (p *PagerDutyProvider) InvalidateLookupCache(kind, name string) int {
+	return p.cfg.Lookup.Cache.Invalidate(kind, name)
+}
+
+// api returns a PagerDuty client bound to the provider's configuration.
+func (p *PagerDutyProvider) api() *common.Client {
+	c := common.NewClient(p.client, p.cfg.APIURL, p.cfg.APIToken)
+	c.Retry = p.cfg.Retry
+	return c
+}
+
 func parseConfig(cfg map[string]any) Config {
 	out := Config{
 		Source: "pagerduty",
@@ -131,6 +219,12 @@ func parseConfig(cfg map[string]any) Config {
 	if v, ok := cfg["apiURL"].(string); ok && v != "" {
 		out.APIURL = strings.TrimSpace(v)
 	}
+	if v, ok := common.IntFromConfig(cfg["rateLimitPerMinute"]); ok && v > 0 {
+		out.RateLimitPerMinute = v
+	}
+	out.Retry = common.ParseRetryPolicy(cfg)
+	out.Lookup = common.ParseLookupOptions(cfg)
+	out.Environments = common.ParseEnvironments(cfg)
 	return out
 }
 
@@ -157,13 +251,15 @@ type pdService struct {
 	} `json:"teams"`
 }
 
-func convertPDService(pdSvc pdService, source string) schema.Service {
+// convertPDService maps a PagerDuty service to the OpsOrch schema. teamEnvs
+// maps team IDs to environments when tag-based environments are configured.
+func convertPDService(pdSvc pdService, cfg Config, teamEnvs map[string]string) schema.Service {
 	svc := schema.Service{
 		ID:   pdSvc.ID,
 		Name: pdSvc.Name,
 		Tags: map[string]string{},
 		Metadata: map[string]any{
-			"source":         source,
+			"source":         cfg.Source,
 			"summary":        pdSvc.Summary,
 			"description":    pdSvc.Description,
 			"status":         pdSvc.Status,
@@ -192,5 +288,19 @@ func convertPDService(pdSvc pdService, source string) schema.Service {
 		svc.Metadata["teams"] = teams
 	}
 
+	if env := serviceEnvironment(pdSvc, cfg, teamEnvs); env != "" {
+		svc.Tags["environment"] = env
+		svc.Metadata["environment"] = env
+	}
+
 	return svc
 }
+
+// serviceEnvironment resolves the environment of a PagerDuty service.
+func serviceEnvironment(pdSvc pdService, cfg Config, teamEnvs map[string]string) string {
+	teamIDs := make([]string, len(pdSvc.Teams))
+	for i, team := range pdSvc.Teams {
+		teamIDs[i] = team.ID
+	}
+	return cfg.Environments.ForService(pdSvc.ID, pdSvc.Name, teamIDs, teamEnvs)
+}
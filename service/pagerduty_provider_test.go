@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-pagerduty-adapter/common"
 )
 
 func TestParseConfigDefaults(t *testing.T) {
@@ -234,6 +236,171 @@ func TestQueryFilters(t *testing.T) {
 	}
 }
 
+func TestQuerySort(t *testing.T) {
+	var sortParam string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sortParam = r.URL.Query().Get("sort_by")
+		json.NewEncoder(w).Encode(map[string]any{
+			"services": []map[string]any{
+				{"id": "PSVC1", "name": "API", "created_at": "2024-03-01T00:00:00Z"},
+				{"id": "PSVC2", "name": "Billing", "created_at": "2025-06-01T00:00:00Z"},
+				{"id": "PSVC3", "name": "Checkout", "created_at": "2023-01-01T00:00:00Z"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{APIToken: "token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+	ctx := context.Background()
+
+	if _, err := p.Query(ctx, schema.ServiceQuery{Metadata: map[string]any{"sort_by": "name:desc"}}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if sortParam != "name:desc" {
+		t.Errorf("sort_by = %q, want name:desc", sortParam)
+	}
+
+	services, err := p.Query(ctx, schema.ServiceQuery{Metadata: map[string]any{"sort_by": "created_at", "sort_order": "desc"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if sortParam != "" {
+		t.Errorf("created_at sort must not be sent to PagerDuty, got %q", sortParam)
+	}
+	if services[0].ID != "PSVC2" || services[2].ID != "PSVC3" {
+		t.Errorf("unexpected order %v, %v, %v", services[0].ID, services[1].ID, services[2].ID)
+	}
+
+	if _, err := p.Query(ctx, schema.ServiceQuery{Metadata: map[string]any{"sort_by": "urgency"}}); err == nil {
+		t.Error("expected error for unsupported sort field")
+	}
+}
+
+func TestQuerySortByCreatedAtReadsAllPages(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("offset") == "0" {
+			json.NewEncoder(w).Encode(map[string]any{
+				"services": []map[string]any{
+					{"id": "PSVC1", "name": "API", "created_at": "2024-03-01T00:00:00Z"},
+					{"id": "PSVC2", "name": "Billing", "created_at": "2023-06-01T00:00:00Z"},
+				},
+				"more": true,
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"services": []map[string]any{
+				{"id": "PSVC3", "name": "Checkout", "created_at": "2025-01-01T00:00:00Z"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg:    Config{APIToken: "token", APIURL: server.URL},
+		client: &http.Client{},
+	}
+
+	services, err := p.Query(context.Background(), schema.ServiceQuery{
+		Limit:    1,
+		Metadata: map[string]any{"sort_by": "created_at:desc"},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected both pages to be read, got %d requests", requests)
+	}
+	if len(services) != 1 || services[0].ID != "PSVC3" {
+		t.Errorf("got %+v, want only PSVC3", services)
+	}
+}
+
+func TestQueryWithEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"services": []map[string]any{
+				{"id": "PSVC1", "name": "payments-prod"},
+				{"id": "PSVC2", "name": "payments-staging"},
+				{"id": "PSVC3", "name": "legacy"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg: Config{
+			APIToken: "token",
+			APIURL:   server.URL,
+			Environments: common.Environments{
+				Pattern: `-(?P<env>prod|staging)$`,
+				Map:     map[string]string{"psvc3": "prod"},
+			},
+		},
+		client: &http.Client{},
+	}
+	if err := p.cfg.Environments.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err := p.Query(context.Background(), schema.ServiceQuery{Scope: schema.QueryScope{Environment: "prod"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(services) != 2 || services[0].ID != "PSVC1" || services[1].ID != "PSVC3" {
+		t.Fatalf("expected PSVC1 and PSVC3, got %+v", services)
+	}
+	if services[0].Tags["environment"] != "prod" || services[0].Metadata["environment"] != "prod" {
+		t.Errorf("expected environment tag and metadata, got %v / %v", services[0].Tags, services[0].Metadata)
+	}
+}
+
+func TestQueryWithEnvironmentPaginates(t *testing.T) {
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+		if r.URL.Query().Get("limit") != "100" {
+			t.Errorf("limit = %s, want full pages while filtering", r.URL.Query().Get("limit"))
+		}
+		pages := map[string][]map[string]any{
+			"0": {{"id": "PSVC1", "name": "api-staging"}, {"id": "PSVC2", "name": "web-staging"}},
+			"2": {{"id": "PSVC3", "name": "api-prod"}, {"id": "PSVC4", "name": "db-staging"}},
+			"4": {{"id": "PSVC5", "name": "web-prod"}, {"id": "PSVC6", "name": "db-prod"}},
+		}
+		json.NewEncoder(w).Encode(map[string]any{"services": pages[offset], "more": offset != "4"})
+	}))
+	defer server.Close()
+
+	p := &PagerDutyProvider{
+		cfg: Config{
+			APIToken:     "token",
+			APIURL:       server.URL,
+			Environments: common.Environments{Pattern: `-(prod|staging)$`},
+		},
+		client: &http.Client{},
+	}
+	if err := p.cfg.Environments.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err := p.Query(context.Background(), schema.ServiceQuery{Limit: 2, Scope: schema.QueryScope{Environment: "prod"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(services) != 2 || services[0].ID != "PSVC3" || services[1].ID != "PSVC5" {
+		t.Fatalf("expected PSVC3 and PSVC5, got %+v", services)
+	}
+	if want := []string{"0", "2", "4"}; !reflect.DeepEqual(offsets, want) {
+		t.Errorf("offsets = %v, want %v", offsets, want)
+	}
+}
+
 func TestQueryWithScope(t *testing.T) {
 	// Mock server that handles both /teams and /services
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -287,6 +454,19 @@ func TestQueryWithScope(t *testing.T) {
 	if len(services) != 1 {
 		t.Errorf("expected 1 service, got %d", len(services))
 	}
+
+	// An unmatched team returns nothing instead of every service; the mock
+	// rejects unscoped service queries.
+	p.cfg.Lookup.Mode = common.MatchExact
+	services, err = p.Query(ctx, schema.ServiceQuery{
+		Scope: schema.QueryScope{Team: "Platform"},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if services == nil || len(services) != 0 {
+		t.Errorf("expected an empty result, got %+v", services)
+	}
 }
 
 func TestQueryAPIError(t *testing.T) {